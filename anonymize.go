@@ -0,0 +1,83 @@
+package http_serde
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// anonymizeIPHeaders truncates the client addresses found in the standard
+// forwarding headers of header.
+func anonymizeIPHeaders(header http.Header) {
+	for _, key := range []string{"X-Forwarded-For", "X-Real-Ip"} {
+		values := header[key]
+		for i, value := range values {
+			entries := strings.Split(value, ",")
+			for j, entry := range entries {
+				entries[j] = anonymizeAddr(strings.TrimSpace(entry))
+			}
+			values[i] = strings.Join(entries, ", ")
+		}
+	}
+	values := header["Forwarded"]
+	for i, value := range values {
+		elements := strings.Split(value, ",")
+		for j, element := range elements {
+			pairs := strings.Split(element, ";")
+			for k, pair := range pairs {
+				key, node, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && (strings.EqualFold(key, "for") || strings.EqualFold(key, "by")) {
+					pairs[k] = key + "=" + anonymizeForwardedNode(node)
+				} else {
+					pairs[k] = strings.TrimSpace(pair)
+				}
+			}
+			elements[j] = strings.Join(pairs, ";")
+		}
+		values[i] = strings.Join(elements, ", ")
+	}
+}
+
+// anonymizeAddr zeroes the last octet of an IPv4 address and the last 80 bits
+// of an IPv6 address, keeping any port. Anything else is returned as is.
+func anonymizeAddr(addr string) string {
+	if ip := net.ParseIP(addr); ip != nil {
+		return anonymizeIP(ip).String()
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return addr
+	}
+	return net.JoinHostPort(anonymizeIP(ip).String(), port)
+}
+
+func anonymizeIP(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32))
+	}
+	return ip.Mask(net.CIDRMask(48, 128))
+}
+
+// anonymizeForwardedNode anonymizes a for or by node of a Forwarded header
+// (RFC 7239), bracketing IPv6 addresses and quoting nodes that need it.
+func anonymizeForwardedNode(node string) string {
+	if unquoted, err := strconv.Unquote(node); err == nil {
+		node = unquoted
+	}
+	if strings.HasPrefix(node, "[") && strings.HasSuffix(node, "]") {
+		node = node[1 : len(node)-1]
+	}
+	anonymized := anonymizeAddr(node)
+	if ip := net.ParseIP(anonymized); ip != nil && ip.To4() == nil {
+		anonymized = "[" + anonymized + "]"
+	}
+	if strings.Contains(anonymized, ":") {
+		return strconv.Quote(anonymized)
+	}
+	return anonymized
+}
@@ -0,0 +1,45 @@
+package http_serde
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnonymizeAddr(t *testing.T) {
+	tests := []struct {
+		it       string
+		addr     string
+		expected string
+	}{
+		{it: "zeroes the last octet of IPv4 addresses", addr: "203.0.113.195", expected: "203.0.113.0"},
+		{it: "keeps IPv4 ports", addr: "203.0.113.195:4711", expected: "203.0.113.0:4711"},
+		{it: "zeroes the last 80 bits of IPv6 addresses", addr: "2001:db8:85a3:8d3:1319:8a2e:370:7348", expected: "2001:db8:85a3::"},
+		{it: "keeps IPv6 ports", addr: "[2001:db8:85a3::7348]:4711", expected: "[2001:db8:85a3::]:4711"},
+		{it: "handles IPv4-mapped IPv6 addresses as IPv4", addr: "::ffff:203.0.113.195", expected: "203.0.113.0"},
+		{it: "keeps values that are not addresses", addr: "unknown", expected: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			require.Equal(t, tt.expected, anonymizeAddr(tt.addr))
+		})
+	}
+}
+
+func TestWithIPAnonymization(t *testing.T) {
+	req := newRequest(t, http.MethodGet, "http://test.test/test", "")
+	req.Header.Set("X-Forwarded-For", "203.0.113.195, 2001:db8:85a3:8d3:1319:8a2e:370:7348,198.51.100.17")
+	req.Header.Set("X-Real-IP", "203.0.113.195")
+	req.Header.Set("Forwarded", `for=192.0.2.43;proto=https, for="[2001:db8:cafe::17]:4711";by=unknown, For=198.51.100.17`)
+
+	ser, err := New(WithIPAnonymization(true)).Serialize(req)
+	require.NoError(t, err)
+	got, err := New().Deserialize(ser)
+	require.NoError(t, err)
+	require.Equal(t, "203.0.113.0, 2001:db8:85a3::, 198.51.100.0", got.Header.Get("X-Forwarded-For"))
+	require.Equal(t, "203.0.113.0", got.Header.Get("X-Real-IP"))
+	require.Equal(t, `for=192.0.2.0;proto=https, for="[2001:db8:cafe::]:4711";by=unknown, For=198.51.100.0`, got.Header.Get("Forwarded"))
+
+	require.Equal(t, "203.0.113.195", req.Header.Get("X-Real-IP"))
+}
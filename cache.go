@@ -0,0 +1,119 @@
+package http_serde
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+)
+
+type cacheEntry struct {
+	key     string
+	request *http.Request
+	body    []byte
+}
+
+// cachingSerde only caches Deserialize, every other method goes straight to
+// the wrapped SerDe.
+type cachingSerde struct {
+	SerDe
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+func fingerprint(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *cachingSerde) Deserialize(serialized []byte) (*http.Request, error) {
+	key := fingerprint(serialized)
+	if entry, ok := c.get(key); ok {
+		return entry.clone(), nil
+	}
+	req, err := c.SerDe.Deserialize(serialized)
+	if err != nil {
+		return nil, err
+	}
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if err := req.Body.Close(); err != nil {
+			return nil, err
+		}
+	}
+	req.Body = nil
+	entry := &cacheEntry{key: key, request: req, body: body}
+	c.add(entry)
+	return entry.clone(), nil
+}
+
+func (c *cachingSerde) get(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry), true
+}
+
+func (c *cachingSerde) add(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[entry.key]; ok {
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// clone hands out a copy of the cached request with its own body reader so
+// callers can neither consume nor mutate the cached state.
+func (e *cacheEntry) clone() *http.Request {
+	req := e.request.Clone(e.request.Context())
+	body := e.body
+	req.Body = http.NoBody
+	req.GetBody = func() (io.ReadCloser, error) {
+		return http.NoBody, nil
+	}
+	if body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+	return req
+}
+
+// NewCachingSerDe wraps inner with an LRU cache of deserialized requests,
+// keyed by the SHA-256 of the serialized bytes and holding up to maxEntries
+// of them. Only Deserialize is cached, every other method goes straight to
+// inner. A maxEntries of zero or less is raised to one, caching only the most
+// recent request. Cached requests are cloned on each hit, with their own body.
+func NewCachingSerDe(inner SerDe, maxEntries int) SerDe {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+	return &cachingSerde{
+		SerDe:      inner,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element, maxEntries),
+		lru:        list.New(),
+	}
+}
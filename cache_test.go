@@ -0,0 +1,109 @@
+package http_serde
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCachingSerDe(t *testing.T) {
+	got := NewCachingSerDe(New(), 2)
+	_, ok := got.(*cachingSerde)
+	require.True(t, ok)
+}
+
+func TestCachingDeserialize(t *testing.T) {
+	serialize := func(t *testing.T, path string, body string) []byte {
+		req, err := http.NewRequest(http.MethodPost, "http://test.test"+path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		ser, err := New().Serialize(req)
+		require.NoError(t, err)
+		return ser
+	}
+	readBody := func(t *testing.T, req *http.Request) string {
+		b, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		return string(b)
+	}
+	tests := []struct {
+		it     string
+		assert func(t *testing.T, c *cachingSerde)
+	}{
+		{
+			it: "returns an error if serialized request is invalid",
+			assert: func(t *testing.T, c *cachingSerde) {
+				req, err := c.Deserialize([]byte("INVALID"))
+				require.Error(t, err)
+				require.Nil(t, req)
+				require.Equal(t, 0, c.lru.Len())
+			},
+		},
+		{
+			it: "returns independent but equal requests on cache hits",
+			assert: func(t *testing.T, c *cachingSerde) {
+				ser := serialize(t, "/test", "test")
+				first, err := c.Deserialize(ser)
+				require.NoError(t, err)
+				first.Header.Set("X-Mutated", "true")
+				require.Equal(t, "test", readBody(t, first))
+
+				second, err := c.Deserialize(ser)
+				require.NoError(t, err)
+				require.NotSame(t, first, second)
+				require.Equal(t, first.Method, second.Method)
+				require.Equal(t, first.URL.String(), second.URL.String())
+				require.Empty(t, second.Header.Get("X-Mutated"))
+				require.Equal(t, "test", readBody(t, second))
+
+				body, err := second.GetBody()
+				require.NoError(t, err)
+				b, err := io.ReadAll(body)
+				require.NoError(t, err)
+				require.Equal(t, "test", string(b))
+				require.Equal(t, 1, c.lru.Len())
+			},
+		},
+		{
+			it: "evicts the least recently used entry",
+			assert: func(t *testing.T, c *cachingSerde) {
+				a, b, d := serialize(t, "/a", "a"), serialize(t, "/b", "b"), serialize(t, "/d", "d")
+				for _, ser := range [][]byte{a, b, a, d} {
+					_, err := c.Deserialize(ser)
+					require.NoError(t, err)
+				}
+				require.Equal(t, 2, c.lru.Len())
+				require.Contains(t, c.entries, fingerprint(a))
+				require.Contains(t, c.entries, fingerprint(d))
+				require.NotContains(t, c.entries, fingerprint(b))
+			},
+		},
+		{
+			it: "is safe for concurrent use",
+			assert: func(t *testing.T, c *cachingSerde) {
+				sers := [][]byte{serialize(t, "/a", "a"), serialize(t, "/b", "b"), serialize(t, "/d", "d")}
+				var wg sync.WaitGroup
+				for i := 0; i < 30; i++ {
+					wg.Add(1)
+					go func(ser []byte) {
+						defer wg.Done()
+						req, err := c.Deserialize(ser)
+						require.NoError(t, err)
+						_, err = io.ReadAll(req.Body)
+						require.NoError(t, err)
+					}(sers[i%len(sers)])
+				}
+				wg.Wait()
+				require.LessOrEqual(t, c.lru.Len(), 2)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			tt.assert(t, NewCachingSerDe(New(), 2).(*cachingSerde))
+		})
+	}
+}
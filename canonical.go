@@ -0,0 +1,67 @@
+package http_serde
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Canonicalize renders a serialized request as deterministic, diff-friendly
+// text meant for golden files rather than the wire. Query parameters are
+// decoded and headers sorted, one per line, Content-Length reflects the
+// decoded body in place of any Transfer-Encoding, and the body follows in its
+// own section, base64 encoded if it is not valid UTF-8.
+func Canonicalize(serialized []byte) (string, error) {
+	req, err := New().Deserialize(serialized)
+	if err != nil {
+		return "", err
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s\n", req.Method, req.URL.EscapedPath(), req.Proto)
+	fmt.Fprintf(&b, "Host: %s\n", req.Host)
+
+	query := req.URL.Query()
+	b.WriteString("\n[query]\n")
+	for _, key := range sortedKeys(query) {
+		for _, value := range query[key] {
+			fmt.Fprintf(&b, "%s = %s\n", key, value)
+		}
+	}
+
+	header := req.Header.Clone()
+	header.Del("Transfer-Encoding")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	b.WriteString("\n[headers]\n")
+	for _, key := range sortedKeys(header) {
+		for _, value := range header[key] {
+			fmt.Fprintf(&b, "%s: %s\n", key, value)
+		}
+	}
+
+	if utf8.Valid(body) {
+		fmt.Fprintf(&b, "\n[body %d bytes]\n", len(body))
+		b.Write(body)
+	} else {
+		fmt.Fprintf(&b, "\n[body %d bytes, base64]\n", len(body))
+		b.WriteString(base64.StdEncoding.EncodeToString(body))
+	}
+	b.WriteString("\n")
+	return b.String(), nil
+}
+
+func sortedKeys(values map[string][]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,69 @@
+package http_serde
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		it       string
+		input    string
+		expected string
+		err      bool
+	}{
+		{
+			it:    "returns an error if serialized request is invalid",
+			input: "INVALID",
+			err:   true,
+		},
+		{
+			it:    "renders sorted headers, decoded query parameters and the body",
+			input: "POST /test?b=2&a=x%20y&b=1 HTTP/1.1\r\nHost: test.test\r\nX-Custom: b\r\nAccept: */*\r\nX-Custom: a\r\nContent-Length: 4\r\n\r\ntest",
+			expected: "POST /test HTTP/1.1\n" +
+				"Host: test.test\n" +
+				"\n[query]\n" +
+				"a = x y\n" +
+				"b = 2\n" +
+				"b = 1\n" +
+				"\n[headers]\n" +
+				"Accept: */*\n" +
+				"Content-Length: 4\n" +
+				"X-Custom: b\n" +
+				"X-Custom: a\n" +
+				"\n[body 4 bytes]\n" +
+				"test\n",
+		},
+		{
+			it:    "renders binary bodies as base64",
+			input: "POST /test HTTP/1.1\r\nHost: test.test\r\nContent-Length: 2\r\n\r\n\xff\x00",
+			expected: "POST /test HTTP/1.1\n" +
+				"Host: test.test\n" +
+				"\n[query]\n" +
+				"\n[headers]\n" +
+				"Content-Length: 2\n" +
+				"\n[body 2 bytes, base64]\n" +
+				"/wA=\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			got, err := Canonicalize([]byte(tt.input))
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestCanonicalizeEquivalentRequests(t *testing.T) {
+	a, err := Canonicalize([]byte("POST /test?a=1&b=2 HTTP/1.1\r\nHost: test.test\r\nAccept: */*\r\nX-Custom: a\r\nContent-Length: 4\r\n\r\ntest"))
+	require.NoError(t, err)
+	b, err := Canonicalize([]byte("POST /test?b=2&a=%31 HTTP/1.1\r\nX-Custom: a\r\nHost: test.test\r\nTransfer-Encoding: chunked\r\naccept: */*\r\n\r\n2\r\nte\r\n2\r\nst\r\n0\r\n\r\n"))
+	require.NoError(t, err)
+	require.Equal(t, a, b)
+}
@@ -0,0 +1,70 @@
+package http_serde
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+type capturingRoundTripper struct {
+	next http.RoundTripper
+	sink func([]byte)
+}
+
+// CapturingRoundTripper returns a RoundTripper that serializes each outgoing
+// request, hands the bytes to sink and then sends the request through next,
+// or http.DefaultTransport if nil. Bodies are read through GetBody when set,
+// otherwise buffered so next still sends them in full. Requests that fail to
+// serialize are still sent, without reaching sink, so capturing never breaks
+// the traffic it observes.
+func CapturingRoundTripper(next http.RoundTripper, sink func([]byte)) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &capturingRoundTripper{next: next, sink: sink}
+}
+
+func (c *capturingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	sent := req
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if req.GetBody != nil {
+			body, err = readGetBody(req)
+		} else {
+			body, err = io.ReadAll(req.Body)
+			_ = req.Body.Close()
+			// the request must not be modified, so next gets a copy
+			// holding the buffered body
+			sent = new(http.Request)
+			*sent = *req
+			sent.Body = io.NopCloser(bytes.NewReader(body))
+			sent.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+		if err != nil {
+			// RoundTrip must close the body even when it fails
+			_ = req.Body.Close()
+			return nil, err
+		}
+	}
+	capture := req.Clone(req.Context())
+	capture.Body = http.NoBody
+	if body != nil {
+		capture.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if ser, err := New().Serialize(capture); err == nil {
+		c.sink(ser)
+	}
+	return c.next.RoundTrip(sent)
+}
+
+func readGetBody(req *http.Request) ([]byte, error) {
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,102 @@
+package http_serde
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yalochat/http-serde/internal/mocks"
+)
+
+func TestCapturingRoundTripper(t *testing.T) {
+	tests := []struct {
+		it    string
+		setup func(t *testing.T) *http.Request
+	}{
+		{
+			it: "captures requests with a replayable body",
+			setup: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodPost, "http://test.test/orders", strings.NewReader("test"))
+				require.NoError(t, err)
+				return req
+			},
+		},
+		{
+			it: "captures requests with a one-shot body",
+			setup: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodPost, "http://test.test/orders", io.NopCloser(bytes.NewBufferString("test")))
+				require.NoError(t, err)
+				req.ContentLength = 4
+				require.Nil(t, req.GetBody)
+				return req
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			var captured [][]byte
+			var sent string
+			rt := CapturingRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				b, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				sent = string(b)
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+			}), func(b []byte) {
+				captured = append(captured, b)
+			})
+
+			req := tt.setup(t)
+			resp, err := (&http.Client{Transport: rt}).Do(req)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, "test", sent)
+			require.Empty(t, req.Header.Get("Content-Length"))
+
+			require.Len(t, captured, 1)
+			got, err := New().Deserialize(captured[0])
+			require.NoError(t, err)
+			require.Equal(t, http.MethodPost, got.Method)
+			require.Equal(t, "/orders", got.URL.Path)
+			require.Equal(t, "test.test", got.Host)
+			b, err := io.ReadAll(got.Body)
+			require.NoError(t, err)
+			require.Equal(t, "test", string(b))
+		})
+	}
+}
+
+func TestCapturingRoundTripperBodiless(t *testing.T) {
+	var captured []byte
+	rt := CapturingRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: req}, nil
+	}), func(b []byte) {
+		captured = b
+	})
+	req, err := http.NewRequest(http.MethodGet, "http://test.test/health", nil)
+	require.NoError(t, err)
+	_, err = rt.RoundTrip(req)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(captured), "GET /health HTTP/1.1\r\nHost: test.test\r\n"))
+}
+
+func TestCapturingRoundTripperGetBodyError(t *testing.T) {
+	body := &mocks.FakeReadCloser{}
+	rt := CapturingRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		t.Fatal("requests whose body cannot be read must not be sent")
+		return nil, nil
+	}), func(b []byte) {
+		t.Fatal("requests whose body cannot be read must not be captured")
+	})
+	req, err := http.NewRequest(http.MethodPost, "http://test.test/orders", body)
+	require.NoError(t, err)
+	req.GetBody = func() (io.ReadCloser, error) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	_, err = rt.RoundTrip(req)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, 1, body.CloseCallCount())
+}
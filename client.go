@@ -0,0 +1,88 @@
+package http_serde
+
+import (
+	"io"
+	"strings"
+)
+
+// ClientClass is the kind of client that probably sent a request, as guessed
+// from its User-Agent.
+type ClientClass int
+
+const (
+	// ClientUnknown is for missing or unrecognized User-Agents.
+	ClientUnknown ClientClass = iota
+	// ClientBrowser is a desktop web browser.
+	ClientBrowser
+	// ClientMobile is a browser or app on a phone or tablet.
+	ClientMobile
+	// ClientBot is a crawler or link previewer.
+	ClientBot
+	// ClientAPI is an HTTP library or command line tool.
+	ClientAPI
+)
+
+func (c ClientClass) String() string {
+	switch c {
+	case ClientBrowser:
+		return "browser"
+	case ClientMobile:
+		return "mobile"
+	case ClientBot:
+		return "bot"
+	case ClientAPI:
+		return "api-client"
+	default:
+		return "unknown"
+	}
+}
+
+// clientRules map lowercase User-Agent fragments to client classes. They are
+// tried in order, so crawlers that pose as browsers are caught first.
+var clientRules = []struct {
+	fragment string
+	class    ClientClass
+}{
+	{"bot", ClientBot},
+	{"crawler", ClientBot},
+	{"spider", ClientBot},
+	{"slurp", ClientBot},
+	{"facebookexternalhit", ClientBot},
+	{"curl/", ClientAPI},
+	{"wget/", ClientAPI},
+	{"python-requests/", ClientAPI},
+	{"python-urllib/", ClientAPI},
+	{"go-http-client/", ClientAPI},
+	{"okhttp/", ClientAPI},
+	{"axios/", ClientAPI},
+	{"postmanruntime/", ClientAPI},
+	{"httpie/", ClientAPI},
+	{"java/", ClientAPI},
+	{"mobile", ClientMobile},
+	{"android", ClientMobile},
+	{"iphone", ClientMobile},
+	{"ipad", ClientMobile},
+	{"mozilla/", ClientBrowser},
+}
+
+// ClassifyClient guesses the kind of client that sent a serialized request
+// from its User-Agent, reading only the head of the request.
+func ClassifyClient(serialized []byte) (ClientClass, error) {
+	if len(headLines(serialized)) == 0 {
+		return ClientUnknown, &ParseError{Reason: "empty request", Err: io.ErrUnexpectedEOF}
+	}
+	if err := checkRequestLine(serialized); err != nil {
+		return ClientUnknown, err
+	}
+	agents := rawHeaderValues(serialized, "User-Agent")
+	if len(agents) == 0 {
+		return ClientUnknown, nil
+	}
+	agent := strings.ToLower(agents[0])
+	for _, rule := range clientRules {
+		if strings.Contains(agent, rule.fragment) {
+			return rule.class, nil
+		}
+	}
+	return ClientUnknown, nil
+}
@@ -0,0 +1,75 @@
+package http_serde
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyClient(t *testing.T) {
+	tests := []struct {
+		it        string
+		userAgent string
+		expected  ClientClass
+	}{
+		{
+			it:        "classifies desktop browsers",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+			expected:  ClientBrowser,
+		},
+		{
+			it:        "classifies mobile browsers",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+			expected:  ClientMobile,
+		},
+		{
+			it:        "classifies crawlers posing as browsers as bots",
+			userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			expected:  ClientBot,
+		},
+		{
+			it:        "classifies HTTP libraries as API clients",
+			userAgent: "curl/8.4.0",
+			expected:  ClientAPI,
+		},
+		{
+			it:        "classifies Android HTTP libraries as API clients",
+			userAgent: "okhttp/4.12.0",
+			expected:  ClientAPI,
+		},
+		{
+			it:        "returns unknown for other agents",
+			userAgent: "custom-agent",
+			expected:  ClientUnknown,
+		},
+		{
+			it:       "returns unknown without a User-Agent",
+			expected: ClientUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			serialized := "GET /test HTTP/1.1\r\nHost: test.test\r\n"
+			if tt.userAgent != "" {
+				serialized += "User-Agent: " + tt.userAgent + "\r\n"
+			}
+			serialized += "Content-Length: 4\r\n\r\ntest"
+			got, err := ClassifyClient([]byte(serialized))
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestClassifyClientErrors(t *testing.T) {
+	_, err := ClassifyClient(nil)
+	require.Error(t, err)
+	_, err = ClassifyClient([]byte("INVALID\r\n\r\n"))
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+}
+
+func TestClientClassString(t *testing.T) {
+	require.Equal(t, "api-client", ClientAPI.String())
+	require.Equal(t, "unknown", ClientClass(42).String())
+}
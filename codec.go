@@ -0,0 +1,175 @@
+package http_serde
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Codec converts serialized requests between the HTTP/1.1 wire format and
+// another encoding. WithCodec plugs one into a SerDe.
+type Codec interface {
+	Encode(wire []byte) ([]byte, error)
+	Decode(encoded []byte) ([]byte, error)
+}
+
+// RawCodec leaves requests in the wire format. It is what FormatWire uses.
+type RawCodec struct{}
+
+func (RawCodec) Encode(wire []byte) ([]byte, error) {
+	return wire, nil
+}
+
+func (RawCodec) Decode(encoded []byte) ([]byte, error) {
+	return encoded, nil
+}
+
+// GzipCodec compresses requests in the wire format with gzip.
+type GzipCodec struct{}
+
+func (GzipCodec) Encode(wire []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(wire); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GzipCodec) Decode(encoded []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(encoded))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// JSONCodec encodes requests as a JSON object with their schema version,
+// method, target, protocol, ordered header list and base64 encoded body.
+// Payloads written with older schema versions are upgraded on decode through
+// the migrations registered with RegisterMigration.
+type JSONCodec struct{}
+
+func (JSONCodec) Encode(wire []byte) ([]byte, error) {
+	r, err := parseStructured(wire)
+	if err != nil {
+		return nil, err
+	}
+	r.Version = structuredVersion
+	return json.Marshal(r)
+}
+
+func (JSONCodec) Decode(encoded []byte) ([]byte, error) {
+	var r structuredRequest
+	if err := unmarshalStructured(encoded, &r); err != nil {
+		return nil, err
+	}
+	return r.wire(), nil
+}
+
+// GobCodec encodes requests with encoding/gob, holding the same fields as
+// JSONCodec, schema version included. Payloads written with older schema
+// versions go through the same migrations as JSONCodec ones, applied to
+// their JSON form.
+type GobCodec struct{}
+
+func (GobCodec) Encode(wire []byte) ([]byte, error) {
+	r, err := parseStructured(wire)
+	if err != nil {
+		return nil, err
+	}
+	r.Version = structuredVersion
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Decode(encoded []byte) ([]byte, error) {
+	var r structuredRequest
+	if err := gob.NewDecoder(bytes.NewReader(encoded)).Decode(&r); err != nil {
+		return nil, err
+	}
+	if r.Version != structuredVersion {
+		payload, err := json.Marshal(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := unmarshalStructured(payload, &r); err != nil {
+			return nil, err
+		}
+	}
+	return r.wire(), nil
+}
+
+// ProtobufCodec encodes requests as the HttpRequest message of
+// proto/http_request.proto. It is what FormatProtobuf uses.
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) Encode(wire []byte) ([]byte, error) {
+	return encodeProtobuf(wire)
+}
+
+func (ProtobufCodec) Decode(encoded []byte) ([]byte, error) {
+	return decodeProtobuf(encoded)
+}
+
+// structuredRequest is a request split into the parts of its wire format,
+// for the codecs that encode them separately.
+type structuredRequest struct {
+	Version int                `json:"version,omitempty"`
+	Method  string             `json:"method"`
+	Target  string             `json:"target"`
+	Proto   string             `json:"proto"`
+	Headers []structuredHeader `json:"headers,omitempty"`
+	Body    []byte             `json:"body,omitempty"`
+}
+
+type structuredHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func parseStructured(wire []byte) (structuredRequest, error) {
+	lines := headLines(wire)
+	if len(lines) == 0 {
+		return structuredRequest{}, &ParseError{Reason: "empty request", Err: io.ErrUnexpectedEOF}
+	}
+	var r structuredRequest
+	rest := ""
+	r.Method, rest, _ = strings.Cut(lines[0].text, " ")
+	r.Target, r.Proto, _ = strings.Cut(rest, " ")
+	for _, line := range lines[1:] {
+		name, value, _ := strings.Cut(line.text, ":")
+		r.Headers = append(r.Headers, structuredHeader{Name: name, Value: strings.TrimLeft(value, " \t")})
+	}
+	r.Body = wire[headEnd(wire):]
+	return r, nil
+}
+
+func (r structuredRequest) wire() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(r.Method)
+	buf.WriteByte(' ')
+	buf.WriteString(r.Target)
+	buf.WriteByte(' ')
+	buf.WriteString(r.Proto)
+	buf.WriteString("\r\n")
+	for _, header := range r.Headers {
+		buf.WriteString(header.Name)
+		buf.WriteString(": ")
+		buf.WriteString(header.Value)
+		buf.WriteString("\r\n")
+	}
+	buf.WriteString("\r\n")
+	buf.Write(r.Body)
+	return buf.Bytes()
+}
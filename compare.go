@@ -0,0 +1,102 @@
+package http_serde
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+)
+
+var volatileHeaders = []string{"Date", "User-Agent"}
+
+// EqualIgnoring reports whether two serialized requests are the same, ignoring
+// the Date and User-Agent headers as well as any header in ignore.
+func EqualIgnoring(a, b []byte, ignore ...string) (bool, error) {
+	s := New()
+	reqA, err := s.Deserialize(a)
+	if err != nil {
+		return false, err
+	}
+	reqB, err := s.Deserialize(b)
+	if err != nil {
+		return false, err
+	}
+	ignored := map[string]bool{}
+	for _, key := range append(volatileHeaders, ignore...) {
+		ignored[http.CanonicalHeaderKey(key)] = true
+	}
+	if !equalHead(reqA, reqB, ignored) {
+		return false, nil
+	}
+	bodyA, err := io.ReadAll(reqA.Body)
+	if err != nil {
+		return false, err
+	}
+	bodyB, err := io.ReadAll(reqB.Body)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(bodyA, bodyB), nil
+}
+
+// EqualSemantic reports whether two requests are the same, letting the
+// comparer registered in bodyComparers for their media type decide whether
+// the bodies are equal. Bodies of other media types are compared byte by byte,
+// and Content-Length is ignored since equal bodies may differ in length. Both
+// bodies are left readable.
+func EqualSemantic(a, b *http.Request, bodyComparers map[string]func([]byte, []byte) bool) (bool, error) {
+	if a == nil || b == nil {
+		return false, errors.New("compare called on nil request")
+	}
+	if !equalHead(a, b, map[string]bool{"Content-Length": true}) {
+		return false, nil
+	}
+	s := &serde{}
+	bodyA, err := s.readBody(a)
+	if err != nil {
+		return false, err
+	}
+	bodyB, err := s.readBody(b)
+	if err != nil {
+		return false, err
+	}
+	mediaType, _, _ := mime.ParseMediaType(a.Header.Get("Content-Type"))
+	if compare, ok := bodyComparers[mediaType]; ok {
+		return compare(bodyA, bodyB), nil
+	}
+	return bytes.Equal(bodyA, bodyB), nil
+}
+
+// EqualJSON is a body comparer for EqualSemantic that treats JSON documents
+// as equal regardless of key order and whitespace.
+func EqualJSON(a, b []byte) bool {
+	var valueA, valueB interface{}
+	if json.Unmarshal(a, &valueA) != nil || json.Unmarshal(b, &valueB) != nil {
+		return bytes.Equal(a, b)
+	}
+	return reflect.DeepEqual(valueA, valueB)
+}
+
+func equalHead(a, b *http.Request, ignored map[string]bool) bool {
+	if a.Method != b.Method || a.URL.String() != b.URL.String() || a.Host != b.Host || a.Proto != b.Proto {
+		return false
+	}
+	return equalHeaders(a.Header, b.Header, ignored)
+}
+
+func equalHeaders(a, b http.Header, ignored map[string]bool) bool {
+	for key, values := range a {
+		if !ignored[http.CanonicalHeaderKey(key)] && !equalValues(values, b[key]) {
+			return false
+		}
+	}
+	for key, values := range b {
+		if !ignored[http.CanonicalHeaderKey(key)] && !equalValues(values, a[key]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package http_serde
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqualIgnoring(t *testing.T) {
+	serialize := func(t *testing.T, body string, headers map[string]string) []byte {
+		req := newRequest(t, http.MethodPost, "http://test.test/test", body)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		return serializeAll(t, req)
+	}
+	tests := []struct {
+		it       string
+		a, b     func(t *testing.T) []byte
+		ignore   []string
+		expected bool
+		err      bool
+	}{
+		{
+			it:  "returns an error if a serialized request is invalid",
+			a:   func(t *testing.T) []byte { return []byte("INVALID") },
+			b:   func(t *testing.T) []byte { return serialize(t, "test", nil) },
+			err: true,
+		},
+		{
+			it: "ignores volatile headers",
+			a: func(t *testing.T) []byte {
+				return serialize(t, "test", map[string]string{"Date": "Mon, 02 Jan 2006 15:04:05 GMT", "User-Agent": "a"})
+			},
+			b: func(t *testing.T) []byte {
+				return serialize(t, "test", map[string]string{"Date": "Tue, 03 Jan 2006 15:04:05 GMT"})
+			},
+			expected: true,
+		},
+		{
+			it: "ignores the given headers",
+			a: func(t *testing.T) []byte {
+				return serialize(t, "test", map[string]string{"X-Request-Id": "1"})
+			},
+			b: func(t *testing.T) []byte {
+				return serialize(t, "test", map[string]string{"X-Request-Id": "2"})
+			},
+			ignore:   []string{"x-request-id"},
+			expected: true,
+		},
+		{
+			it: "compares other headers",
+			a: func(t *testing.T) []byte {
+				return serialize(t, "test", map[string]string{"X-Request-Id": "1"})
+			},
+			b: func(t *testing.T) []byte {
+				return serialize(t, "test", map[string]string{"X-Request-Id": "2"})
+			},
+			expected: false,
+		},
+		{
+			it:       "compares bodies",
+			a:        func(t *testing.T) []byte { return serialize(t, "test", nil) },
+			b:        func(t *testing.T) []byte { return serialize(t, "tset", nil) },
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			got, err := EqualIgnoring(tt.a(t), tt.b(t), tt.ignore...)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestEqualSemantic(t *testing.T) {
+	comparers := map[string]func([]byte, []byte) bool{"application/json": EqualJSON}
+	jsonRequest := func(t *testing.T, body string) *http.Request {
+		req := newRequest(t, http.MethodPost, "http://test.test/test", body)
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		return req
+	}
+	tests := []struct {
+		it       string
+		a, b     func(t *testing.T) *http.Request
+		expected bool
+		err      bool
+	}{
+		{
+			it:  "returns an error if a request is nil",
+			a:   func(t *testing.T) *http.Request { return nil },
+			b:   func(t *testing.T) *http.Request { return jsonRequest(t, "{}") },
+			err: true,
+		},
+		{
+			it:       "treats JSON bodies with reordered keys and whitespace as equal",
+			a:        func(t *testing.T) *http.Request { return jsonRequest(t, `{"a":1,"b":[1,2]}`) },
+			b:        func(t *testing.T) *http.Request { return jsonRequest(t, "{\n  \"b\": [1, 2],\n  \"a\": 1\n}") },
+			expected: true,
+		},
+		{
+			it:       "compares JSON values",
+			a:        func(t *testing.T) *http.Request { return jsonRequest(t, `{"a":1,"b":[1,2]}`) },
+			b:        func(t *testing.T) *http.Request { return jsonRequest(t, `{"a":1,"b":[2,1]}`) },
+			expected: false,
+		},
+		{
+			it: "compares bodies without a comparer byte by byte",
+			a: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodPost, "http://test.test/test", `{"a":1,"b":2}`)
+			},
+			b: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodPost, "http://test.test/test", `{"b":2,"a":1}`)
+			},
+			expected: false,
+		},
+		{
+			it: "compares heads",
+			a:  func(t *testing.T) *http.Request { return jsonRequest(t, "{}") },
+			b: func(t *testing.T) *http.Request {
+				req := jsonRequest(t, "{}")
+				req.Header.Set("X-Request-Id", "1")
+				return req
+			},
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			a, b := tt.a(t), tt.b(t)
+			got, err := EqualSemantic(a, b, comparers)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, got)
+			_, err = New().Serialize(a)
+			require.NoError(t, err)
+		})
+	}
+}
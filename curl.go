@@ -0,0 +1,178 @@
+package http_serde
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// curlSwitches are curl flags without an argument that do not change the
+// request and are therefore ignored.
+var curlSwitches = map[string]bool{
+	"-s": true, "--silent": true,
+	"-S": true, "--show-error": true,
+	"-v": true, "--verbose": true,
+	"-i": true, "--include": true,
+	"-L": true, "--location": true,
+	"-k": true, "--insecure": true,
+	"--compressed": true,
+}
+
+// DeserializeCURL rebuilds a request from a curl command line. Only a subset
+// of curl is understood: -X, -H, -d/--data, --data-raw, --data-binary and the
+// URL, with shell-style single and double quoting.
+func DeserializeCURL(command string) (*http.Request, error) {
+	args, err := splitCommand(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 && args[0] == "curl" {
+		args = args[1:]
+	}
+	var (
+		method, target string
+		headers        []string
+		data           []string
+		hasData        bool
+	)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if target != "" {
+				return nil, fmt.Errorf("curl: unexpected argument %q", arg)
+			}
+			target = arg
+			continue
+		}
+		if curlSwitches[arg] {
+			continue
+		}
+		name, value, attached := arg, "", false
+		if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			name, value, attached = arg[:2], arg[2:], true
+		}
+		if !attached {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("curl: missing value for %s", name)
+			}
+			i++
+			value = args[i]
+		}
+		switch name {
+		case "-X", "--request":
+			method = value
+		case "-H", "--header":
+			headers = append(headers, value)
+		case "-d", "--data", "--data-raw", "--data-binary", "--data-ascii":
+			data = append(data, value)
+			hasData = true
+		case "--url":
+			target = value
+		default:
+			return nil, fmt.Errorf("curl: unsupported option %s", name)
+		}
+	}
+	if target == "" {
+		return nil, errors.New("curl: missing URL")
+	}
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+	if method == "" {
+		method = http.MethodGet
+		if hasData {
+			method = http.MethodPost
+		}
+	}
+	body := strings.Join(data, "&")
+	req, err := http.NewRequest(method, target, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if !hasData {
+		req.Body = http.NoBody
+		req.GetBody = nil
+	}
+	for _, header := range headers {
+		key, value, ok := strings.Cut(header, ":")
+		if !ok {
+			return nil, fmt.Errorf("curl: malformed header %q", header)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if http.CanonicalHeaderKey(key) == "Host" {
+			req.Host = value
+			continue
+		}
+		req.Header.Add(key, value)
+	}
+	if hasData && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	return req, nil
+}
+
+// splitCommand splits a shell command line into arguments, honoring single
+// quotes, double quotes, backslash escapes and line continuations.
+func splitCommand(command string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   byte
+	)
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				current.WriteByte(c)
+			}
+		case quote == '"':
+			switch {
+			case c == '"':
+				quote = 0
+			case c == '\\' && i+1 < len(command) && strings.IndexByte("\"\\$`\n", command[i+1]) >= 0:
+				i++
+				if command[i] != '\n' {
+					current.WriteByte(command[i])
+				}
+			default:
+				current.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == '\\':
+			if i+1 < len(command) {
+				i++
+				if command[i] == '\n' || command[i] == '\r' {
+					if command[i] == '\r' && i+1 < len(command) && command[i+1] == '\n' {
+						i++
+					}
+					continue
+				}
+				current.WriteByte(command[i])
+				inArg = true
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteByte(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("curl: unterminated quote")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,99 @@
+package http_serde
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeserializeCURL(t *testing.T) {
+	tests := []struct {
+		it      string
+		command string
+		assert  func(t *testing.T, req *http.Request, err error)
+	}{
+		{
+			it: "parses method, url, headers and body",
+			command: `curl -X PUT 'http://test.test/test?foo=bar' \
+  -H 'Content-Type: application/json' \
+  -H "X-Custom: a" -H "X-Custom: b" \
+  --data-binary '{"a":"b c"}'`,
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				require.Equal(t, http.MethodPut, req.Method)
+				require.Equal(t, "http://test.test/test?foo=bar", req.URL.String())
+				require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+				require.Equal(t, []string{"a", "b"}, req.Header.Values("X-Custom"))
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, `{"a":"b c"}`, string(body))
+				require.Equal(t, int64(len(body)), req.ContentLength)
+			},
+		},
+		{
+			it:      "defaults to POST with a form content type when data is given",
+			command: `curl http://test.test/test -d a=1 --data "b=\"2\""`,
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				require.Equal(t, http.MethodPost, req.Method)
+				require.Equal(t, "application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, `a=1&b="2"`, string(body))
+			},
+		},
+		{
+			it:      "defaults to GET without a body",
+			command: `curl -s -XGET test.test/test -H 'Host: other.test'`,
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				require.Equal(t, http.MethodGet, req.Method)
+				require.Equal(t, "http://test.test/test", req.URL.String())
+				require.Equal(t, "other.test", req.Host)
+				require.Equal(t, int64(0), req.ContentLength)
+			},
+		},
+		{
+			it:      "produces requests that serialize",
+			command: `curl -X POST http://test.test/test -H 'Content-Type: text/plain' --data-raw 'test'`,
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				ser, err := New().Serialize(req)
+				require.NoError(t, err)
+				require.Equal(t, "POST /test HTTP/1.1\r\nHost: test.test\r\nContent-Length: 4\r\nContent-Type: text/plain\r\n\r\ntest", string(ser))
+			},
+		},
+		{
+			it:      "returns an error for unterminated quotes",
+			command: `curl 'http://test.test/test`,
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.Error(t, err)
+				require.Nil(t, req)
+			},
+		},
+		{
+			it:      "returns an error for unsupported options",
+			command: `curl -F a=@file http://test.test/test`,
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.Error(t, err)
+				require.Nil(t, req)
+			},
+		},
+		{
+			it:      "returns an error without a URL",
+			command: `curl -X GET`,
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.Error(t, err)
+				require.Nil(t, req)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			req, err := DeserializeCURL(tt.command)
+			tt.assert(t, req, err)
+		})
+	}
+}
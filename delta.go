@@ -0,0 +1,136 @@
+package http_serde
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// delta is a serialized request expressed against a base request: fields
+// are only set where they differ, and headers the base already holds are
+// referenced by their index in it.
+type delta struct {
+	Method   string        `json:"m,omitempty"`
+	Target   string        `json:"t,omitempty"`
+	Proto    string        `json:"p,omitempty"`
+	Headers  []deltaHeader `json:"h,omitempty"`
+	Body     []byte        `json:"b,omitempty"`
+	KeepBody bool          `json:"kb,omitempty"`
+}
+
+// deltaHeader is either a reference to a header of the base request or a
+// header of its own.
+type deltaHeader struct {
+	Base  *int   `json:"i,omitempty"`
+	Name  string `json:"n,omitempty"`
+	Value string `json:"v,omitempty"`
+}
+
+// SerializeDelta serializes request as its differences from base, which is
+// much smaller than the full request for families of similar requests. Both
+// requests are serialized in the wire format with default options, and the
+// same base must be given to ApplyDelta to rebuild the request.
+func SerializeDelta(base, request *http.Request) ([]byte, error) {
+	b, err := structuredOf(base)
+	if err != nil {
+		return nil, err
+	}
+	r, err := structuredOf(request)
+	if err != nil {
+		return nil, err
+	}
+	var d delta
+	if r.Method != b.Method {
+		d.Method = r.Method
+	}
+	if r.Target != b.Target {
+		d.Target = r.Target
+	}
+	if r.Proto != b.Proto {
+		d.Proto = r.Proto
+	}
+	indexes := make(map[structuredHeader][]int, len(b.Headers))
+	for i, header := range b.Headers {
+		indexes[header] = append(indexes[header], i)
+	}
+	used := make(map[int]bool, len(b.Headers))
+	sameHeaders := len(r.Headers) == len(b.Headers)
+	for i, header := range r.Headers {
+		ref := -1
+		for _, j := range indexes[header] {
+			if !used[j] {
+				ref = j
+				break
+			}
+		}
+		if ref < 0 {
+			d.Headers = append(d.Headers, deltaHeader{Name: header.Name, Value: header.Value})
+			sameHeaders = false
+			continue
+		}
+		used[ref] = true
+		d.Headers = append(d.Headers, deltaHeader{Base: &ref})
+		sameHeaders = sameHeaders && ref == i
+	}
+	if sameHeaders {
+		d.Headers = nil
+	}
+	if bytes.Equal(r.Body, b.Body) {
+		d.KeepBody = true
+	} else {
+		d.Body = r.Body
+	}
+	return json.Marshal(d)
+}
+
+// ApplyDelta rebuilds a request serialized by SerializeDelta against base.
+func ApplyDelta(base *http.Request, serialized []byte) (*http.Request, error) {
+	b, err := structuredOf(base)
+	if err != nil {
+		return nil, err
+	}
+	var d delta
+	if err := json.Unmarshal(serialized, &d); err != nil {
+		return nil, err
+	}
+	r := b
+	if d.Method != "" {
+		r.Method = d.Method
+	}
+	if d.Target != "" {
+		r.Target = d.Target
+	}
+	if d.Proto != "" {
+		r.Proto = d.Proto
+	}
+	if d.Headers != nil {
+		r.Headers = make([]structuredHeader, 0, len(d.Headers))
+		for _, header := range d.Headers {
+			if header.Base == nil {
+				r.Headers = append(r.Headers, structuredHeader{Name: header.Name, Value: header.Value})
+				continue
+			}
+			if *header.Base < 0 || *header.Base >= len(b.Headers) {
+				return nil, fmt.Errorf("delta references header %d of a base with %d headers", *header.Base, len(b.Headers))
+			}
+			r.Headers = append(r.Headers, b.Headers[*header.Base])
+		}
+	}
+	if !d.KeepBody {
+		r.Body = d.Body
+	}
+	return New().Deserialize(r.wire())
+}
+
+func structuredOf(request *http.Request) (structuredRequest, error) {
+	if request == nil {
+		return structuredRequest{}, errors.New("delta called on nil request")
+	}
+	wire, err := New().Serialize(request)
+	if err != nil {
+		return structuredRequest{}, err
+	}
+	return parseStructured(wire)
+}
@@ -0,0 +1,86 @@
+package http_serde
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelta(t *testing.T) {
+	newBase := func(t *testing.T) *http.Request {
+		req := newRepresentativeRequest(t)
+		req.Header.Set("X-Request-Id", "1")
+		return req
+	}
+	tests := []struct {
+		it      string
+		setup   func(t *testing.T) *http.Request
+		compact bool
+	}{
+		{
+			it:      "round-trips the base itself",
+			setup:   newBase,
+			compact: true,
+		},
+		{
+			it: "round-trips a changed header",
+			setup: func(t *testing.T) *http.Request {
+				req := newBase(t)
+				req.Header.Set("X-Request-Id", "2")
+				return req
+			},
+			compact: true,
+		},
+		{
+			it: "round-trips a changed body",
+			setup: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodPost, "http://test.test/api/v1/orders?expand=items", strings.NewReader(`{"customer":"c-456"}`))
+				require.NoError(t, err)
+				req.Header = newBase(t).Header
+				return req
+			},
+			compact: true,
+		},
+		{
+			it: "round-trips removed and reordered headers",
+			setup: func(t *testing.T) *http.Request {
+				req := newBase(t)
+				req.Header.Del("Authorization")
+				req.Header["X-Custom"] = []string{"b", "a"}
+				return req
+			},
+			compact: true,
+		},
+		{
+			it: "round-trips a different method and target",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodGet, "http://test.test/api/v1/orders/1", "")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			delta, err := SerializeDelta(newBase(t), tt.setup(t))
+			require.NoError(t, err)
+			got, err := ApplyDelta(newBase(t), delta)
+			require.NoError(t, err)
+
+			expected := serializeAll(t, tt.setup(t))
+			require.Equal(t, string(expected), string(serializeAll(t, got)))
+			if tt.compact {
+				require.Less(t, len(delta), len(expected)/2)
+			}
+		})
+	}
+}
+
+func TestDeltaErrors(t *testing.T) {
+	_, err := SerializeDelta(nil, newRequest(t, http.MethodGet, "http://test.test/test", ""))
+	require.Error(t, err)
+	_, err = ApplyDelta(newRequest(t, http.MethodGet, "http://test.test/test", ""), []byte("INVALID"))
+	require.Error(t, err)
+	_, err = ApplyDelta(newRequest(t, http.MethodGet, "http://test.test/test", ""), []byte(`{"h":[{"i":42}]}`))
+	require.ErrorContains(t, err, "delta references header 42")
+}
@@ -0,0 +1,155 @@
+package http_serde
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+)
+
+type (
+	headerOrderKey struct{}
+	headerCaseKey  struct{}
+	requestLineKey struct{}
+)
+
+// splitDump separates a dumped request into its request line, header lines
+// and body.
+func splitDump(dump []byte) (string, []string, []byte) {
+	head, body, _ := bytes.Cut(dump, []byte("\r\n\r\n"))
+	lines := strings.Split(string(head), "\r\n")
+	return lines[0], lines[1:], body
+}
+
+func joinDump(requestLine string, headers []string, body []byte, eol string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(requestLine)
+	buf.WriteString(eol)
+	for _, header := range headers {
+		buf.WriteString(header)
+		buf.WriteString(eol)
+	}
+	buf.WriteString(eol)
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+func headerKey(line string) string {
+	key, _, _ := strings.Cut(line, ":")
+	return http.CanonicalHeaderKey(key)
+}
+
+// withHeaderOrder records the order in which header keys first appeared in a
+// serialized request, so it can be restored when serializing again.
+func withHeaderOrder(req *http.Request, serialized []byte) *http.Request {
+	var order []string
+	seen := map[string]bool{}
+	lines := headLines(serialized)
+	for _, line := range lines[1:] {
+		key := headerKey(line.text)
+		if !seen[key] {
+			seen[key] = true
+			order = append(order, key)
+		}
+	}
+	return req.WithContext(context.WithValue(req.Context(), headerOrderKey{}, order))
+}
+
+// withHeaderCase records how each header key was spelled in a serialized
+// request, before http.ReadRequest canonicalized it, so the spelling can be
+// restored when serializing again. The first spelling of a key wins.
+func withHeaderCase(req *http.Request, serialized []byte) *http.Request {
+	spellings := map[string]string{}
+	lines := headLines(serialized)
+	for _, line := range lines[1:] {
+		key, _, _ := strings.Cut(line.text, ":")
+		key = strings.TrimSpace(key)
+		canonical := http.CanonicalHeaderKey(key)
+		if _, ok := spellings[canonical]; !ok {
+			spellings[canonical] = key
+		}
+	}
+	return req.WithContext(context.WithValue(req.Context(), headerCaseKey{}, spellings))
+}
+
+// withRawRequestLine records the request line of a serialized request as it
+// was, before normalization, so it can be written verbatim when serializing
+// again.
+func withRawRequestLine(req *http.Request, serialized []byte) *http.Request {
+	line, _, _ := bytes.Cut(serialized, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	return req.WithContext(context.WithValue(req.Context(), requestLineKey{}, string(line)))
+}
+
+// recaseHeaders spells the keys of header lines as recorded in spellings.
+func recaseHeaders(headers []string, spellings map[string]string) []string {
+	for i, line := range headers {
+		key, rest, _ := strings.Cut(line, ":")
+		if spelling, ok := spellings[http.CanonicalHeaderKey(key)]; ok {
+			headers[i] = spelling + ":" + rest
+		}
+	}
+	return headers
+}
+
+// orderHeaders sorts header lines following the recorded order, keeping the
+// relative order of values for the same key. Unknown keys keep their place
+// after the known ones.
+func orderHeaders(headers []string, order []string) []string {
+	rank := make(map[string]int, len(order))
+	for i, key := range order {
+		rank[key] = i
+	}
+	ordered := make([]string, 0, len(headers))
+	for _, key := range order {
+		for _, line := range headers {
+			if headerKey(line) == key {
+				ordered = append(ordered, line)
+			}
+		}
+	}
+	for _, line := range headers {
+		if _, ok := rank[headerKey(line)]; !ok {
+			ordered = append(ordered, line)
+		}
+	}
+	return ordered
+}
+
+// rewrite applies the options that act on the dumped bytes rather than on
+// the request itself.
+func (s *serde) rewrite(request *http.Request, dump []byte) []byte {
+	if s.trailingSlash != TrailingSlashKeep {
+		dump = rewriteRequestLine(dump, s.trailingSlash.apply)
+	}
+	if raw, ok := request.Context().Value(requestLineKey{}).(string); ok && s.rawRequestLine {
+		// a request line changed since it was recorded no longer matches it
+		dump = rewriteRequestLine(dump, func(line string) string {
+			if collapseRequestLine(raw) == line {
+				return raw
+			}
+			return line
+		})
+	}
+	order, _ := request.Context().Value(headerOrderKey{}).([]string)
+	reorder := s.preserveHeaderOrder && order != nil
+	spellings, _ := request.Context().Value(headerCaseKey{}).(map[string]string)
+	recase := s.preserveHeaderCase && spellings != nil
+	if !reorder && !recase && !s.lowercaseHeaders && s.lineEnding == LineEndingCRLF {
+		return dump
+	}
+	requestLine, headers, body := splitDump(dump)
+	if reorder {
+		headers = orderHeaders(headers, order)
+	}
+	if recase {
+		headers = recaseHeaders(headers, spellings)
+	}
+	if s.lowercaseHeaders {
+		for i, line := range headers {
+			key, rest, _ := strings.Cut(line, ":")
+			headers[i] = strings.ToLower(key) + ":" + rest
+		}
+	}
+	return joinDump(requestLine, headers, body, s.lineEnding.eol())
+}
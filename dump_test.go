@@ -0,0 +1,258 @@
+package http_serde
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreserveHeaderOrder(t *testing.T) {
+	serialized := []byte(strings.Join([]string{
+		"POST /test HTTP/1.1",
+		"X-B: b",
+		"Host: test.test",
+		"Accept: */*",
+		"X-A: a1",
+		"Content-Length: 4",
+		"X-A: a2",
+		"",
+		"test",
+	}, "\r\n"))
+	tests := []struct {
+		it     string
+		opts   []Option
+		setup  func(t *testing.T, s SerDe) *http.Request
+		assert func(t *testing.T, b []byte, err error)
+	}{
+		{
+			it:   "round-trips the original header order",
+			opts: []Option{WithPreserveHeaderOrder(true)},
+			setup: func(t *testing.T, s SerDe) *http.Request {
+				req, err := s.Deserialize(serialized)
+				require.NoError(t, err)
+				return req
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.Equal(t, strings.Join([]string{
+					"POST /test HTTP/1.1",
+					"X-B: b",
+					"Host: test.test",
+					"Accept: */*",
+					"X-A: a1",
+					"X-A: a2",
+					"Content-Length: 4",
+					"",
+					"test",
+				}, "\r\n"), string(b))
+			},
+		},
+		{
+			it:   "sorts headers added after deserializing after the known ones",
+			opts: []Option{WithPreserveHeaderOrder(true)},
+			setup: func(t *testing.T, s SerDe) *http.Request {
+				req, err := s.Deserialize(serialized)
+				require.NoError(t, err)
+				req.Header.Set("X-New", "new")
+				return req
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.True(t, strings.HasSuffix(string(b), "Content-Length: 4\r\nX-New: new\r\n\r\ntest"))
+			},
+		},
+		{
+			it:   "falls back to sorted headers when the order is unknown",
+			opts: []Option{WithPreserveHeaderOrder(true)},
+			setup: func(t *testing.T, s SerDe) *http.Request {
+				req := newRequest(t, http.MethodGet, "http://test.test/test", "")
+				req.Header.Set("X-B", "b")
+				req.Header.Set("Accept", "*/*")
+				return req
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.Equal(t, strings.Join([]string{
+					"GET /test HTTP/1.1",
+					"Host: test.test",
+					"Accept: */*",
+					"Content-Length: 0",
+					"X-B: b",
+					"",
+					"",
+				}, "\r\n"), string(b))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			s := New(tt.opts...)
+			got, err := s.Serialize(tt.setup(t, s))
+			tt.assert(t, got, err)
+		})
+	}
+}
+
+func TestHeaderCanonicalization(t *testing.T) {
+	serialized := []byte(strings.Join([]string{
+		"POST /test HTTP/1.1",
+		"host: test.test",
+		"x-custom-KEY: a",
+		"X-Custom-Key: b",
+		"content-length: 4",
+		"",
+		"test",
+	}, "\r\n"))
+	tests := []struct {
+		it     string
+		opts   []Option
+		setup  func(t *testing.T, s SerDe) *http.Request
+		assert func(t *testing.T, b []byte, err error)
+	}{
+		{
+			it:   "round-trips the original spelling of header keys",
+			opts: []Option{WithHeaderCanonicalization(false), WithPreserveHeaderOrder(true)},
+			setup: func(t *testing.T, s SerDe) *http.Request {
+				req, err := s.Deserialize(serialized)
+				require.NoError(t, err)
+				return req
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.Equal(t, strings.Join([]string{
+					"POST /test HTTP/1.1",
+					"host: test.test",
+					"x-custom-KEY: a",
+					"x-custom-KEY: b",
+					"content-length: 4",
+					"",
+					"test",
+				}, "\r\n"), string(b))
+			},
+		},
+		{
+			it:   "canonicalizes header keys by default",
+			opts: []Option{WithPreserveHeaderOrder(true)},
+			setup: func(t *testing.T, s SerDe) *http.Request {
+				req, err := s.Deserialize(serialized)
+				require.NoError(t, err)
+				return req
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.Contains(t, string(b), "\r\nX-Custom-Key: a\r\nX-Custom-Key: b\r\n")
+			},
+		},
+		{
+			it:   "serializes keys set directly on the header as they are",
+			opts: []Option{WithHeaderCanonicalization(false)},
+			setup: func(t *testing.T, s SerDe) *http.Request {
+				req := newRequest(t, http.MethodGet, "http://test.test/test", "")
+				req.Header["x-custom-KEY"] = []string{"a"}
+				return req
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.Contains(t, string(b), "\r\nx-custom-KEY: a\r\n")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			s := New(tt.opts...)
+			got, err := s.Serialize(tt.setup(t, s))
+			tt.assert(t, got, err)
+		})
+	}
+}
+
+func TestLowercaseHeaders(t *testing.T) {
+	s := New(WithLowercaseHeaders(true))
+	req := newRequest(t, http.MethodPost, "http://test.test/test", "test")
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("X-Request-Id", "1")
+	ser, err := s.Serialize(req)
+	require.NoError(t, err)
+	require.Equal(t, strings.Join([]string{
+		"POST /test HTTP/1.1",
+		"host: test.test",
+		"content-length: 4",
+		"content-type: text/plain",
+		"x-request-id: 1",
+		"",
+		"test",
+	}, "\r\n"), string(ser))
+	require.Equal(t, "text/plain", req.Header.Get("Content-Type"))
+
+	got, err := s.Deserialize(ser)
+	require.NoError(t, err)
+	require.Equal(t, "test.test", got.Host)
+	require.Equal(t, "1", got.Header.Get("X-Request-Id"))
+	require.Equal(t, int64(4), got.ContentLength)
+}
+
+func TestRawRequestLine(t *testing.T) {
+	tests := []struct {
+		it     string
+		opts   []Option
+		line   string
+		setup  func(req *http.Request)
+		assert func(t *testing.T, b []byte, err error)
+	}{
+		{
+			it:   "round-trips a request line with double spaces",
+			opts: []Option{WithRawRequestLine(true)},
+			line: "GET  /test  HTTP/1.1",
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.True(t, strings.HasPrefix(string(b), "GET  /test  HTTP/1.1\r\nHost: test.test\r\n"))
+			},
+		},
+		{
+			it:   "round-trips unusual casing and tabs",
+			opts: []Option{WithRawRequestLine(true)},
+			line: "get\t/test http/1.1",
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.True(t, strings.HasPrefix(string(b), "get\t/test http/1.1\r\n"))
+			},
+		},
+		{
+			it:   "writes the standard line once the target changed",
+			opts: []Option{WithRawRequestLine(true)},
+			line: "GET  /test  HTTP/1.1",
+			setup: func(req *http.Request) {
+				req.RequestURI = "/other"
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.True(t, strings.HasPrefix(string(b), "GET /other HTTP/1.1\r\n"))
+			},
+		},
+		{
+			it:   "rejects such lines by default",
+			line: "GET  /test  HTTP/1.1",
+			assert: func(t *testing.T, b []byte, err error) {
+				var parseErr *ParseError
+				require.ErrorAs(t, err, &parseErr)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			s := New(tt.opts...)
+			req, err := s.Deserialize([]byte(tt.line + "\r\nHost: test.test\r\n\r\n"))
+			if err != nil {
+				tt.assert(t, nil, err)
+				return
+			}
+			if tt.setup != nil {
+				tt.setup(req)
+			}
+			got, err := s.Serialize(req)
+			tt.assert(t, got, err)
+		})
+	}
+}
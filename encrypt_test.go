@@ -0,0 +1,118 @@
+package http_serde
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+	tests := []struct {
+		it     string
+		opts   []Option
+		setup  func(t *testing.T, ser []byte) []byte
+		assert func(t *testing.T, req *http.Request, err error)
+	}{
+		{
+			it:   "round-trips requests with the same key",
+			opts: []Option{WithEncryption(key)},
+			setup: func(t *testing.T, ser []byte) []byte {
+				require.NotContains(t, string(ser), "test.test")
+				return ser
+			},
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "test.test", req.Host)
+				b, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, strings.Repeat("test", 100), string(b))
+			},
+		},
+		{
+			it:   "composes with compression",
+			opts: []Option{WithEncryption(key), WithCodec(GzipCodec{})},
+			setup: func(t *testing.T, ser []byte) []byte {
+				require.Less(t, len(ser), 200)
+				return ser
+			},
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "/test", req.URL.Path)
+			},
+		},
+		{
+			it:   "fails on tampered payloads",
+			opts: []Option{WithEncryption(key)},
+			setup: func(t *testing.T, ser []byte) []byte {
+				ser[len(ser)-1] ^= 1
+				return ser
+			},
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.ErrorIs(t, err, ErrDecryptionFailed)
+				require.Nil(t, req)
+			},
+		},
+		{
+			it:   "fails on truncated payloads",
+			opts: []Option{WithEncryption(key)},
+			setup: func(t *testing.T, ser []byte) []byte {
+				return ser[:4]
+			},
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.ErrorIs(t, err, ErrDecryptionFailed)
+				require.Nil(t, req)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			s := New(tt.opts...)
+			ser, err := s.Serialize(newRequest(t, http.MethodPost, "http://test.test/test", strings.Repeat("test", 100)))
+			require.NoError(t, err)
+			req, err := s.Deserialize(tt.setup(t, ser))
+			tt.assert(t, req, err)
+		})
+	}
+}
+
+func TestWithEncryptionWrongKey(t *testing.T) {
+	ser, err := New(WithEncryption(bytes.Repeat([]byte("a"), 16))).Serialize(newRequest(t, http.MethodGet, "http://test.test/test", ""))
+	require.NoError(t, err)
+	_, err = New(WithEncryption(bytes.Repeat([]byte("b"), 16))).Deserialize(ser)
+	require.ErrorIs(t, err, ErrDecryptionFailed)
+}
+
+func TestWithEncryptionInvalidKey(t *testing.T) {
+	_, err := New(WithEncryption([]byte("short"))).Serialize(newRequest(t, http.MethodGet, "http://test.test/test", ""))
+	require.Error(t, err)
+}
+
+func TestWithEncryptionMetadata(t *testing.T) {
+	s := New(WithEncryption(bytes.Repeat([]byte("k"), 32)), WithCodec(GzipCodec{}))
+	metadata := Metadata{
+		Cookies: []*http.Cookie{{Name: "session", Value: "SECRET"}},
+		Tags:    map[string]string{"tenant": "acme"},
+	}
+	ser, err := s.SerializeWithMetadata(newRequest(t, http.MethodGet, "http://test.test/test", ""), metadata)
+	require.NoError(t, err)
+	require.NotContains(t, string(ser), "SECRET")
+	require.NotContains(t, string(ser), "acme")
+	require.False(t, bytes.HasPrefix(ser, metadataMagic))
+
+	req, got, err := s.DeserializeWithMetadata(ser)
+	require.NoError(t, err)
+	require.Equal(t, "test.test", req.Host)
+	require.Equal(t, "SECRET", got.Cookies[0].Value)
+	require.Equal(t, metadata.Tags, got.Tags)
+
+	ser, footprint, err := s.SerializeWithFootprint(newRequest(t, http.MethodGet, "http://test.test/test", ""))
+	require.NoError(t, err)
+	_, got, err = s.DeserializeWithMetadata(ser)
+	require.NoError(t, err)
+	require.Equal(t, footprint.HeaderBytes, got.Footprint.HeaderBytes)
+}
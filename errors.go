@@ -0,0 +1,51 @@
+package http_serde
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrHostMismatch             = errors.New("host header does not match absolute request target")
+	ErrMalformedMetadata        = errors.New("malformed request metadata")
+	ErrMalformedProtobuf        = errors.New("malformed protobuf request")
+	ErrInvalidHeaderValue       = errors.New("invalid header value")
+	ErrMalformedFragment        = errors.New("malformed fragment")
+	ErrMissingFragment          = errors.New("missing fragment")
+	ErrDuplicateFragment        = errors.New("duplicate fragment")
+	ErrBodyReadTimeout          = errors.New("timed out reading request body")
+	ErrBodyLengthMismatch       = errors.New("body length does not match content length")
+	ErrInputTooLarge            = errors.New("serialized request exceeds maximum input size")
+	ErrEarlyData                = errors.New("request was sent in TLS early data")
+	ErrNotMultipart             = errors.New("request is not multipart")
+	ErrMissingPart              = errors.New("missing multipart part")
+	ErrMalformedTunnel          = errors.New("malformed tunnel")
+	ErrTooManyHeaderValues      = errors.New("too many values for header")
+	ErrUnsupportedSchemaVersion = errors.New("unsupported structured schema version")
+	ErrDecryptionFailed         = errors.New("decryption failed")
+	ErrVaryWildcard             = errors.New("vary * matches no other request")
+	ErrURLTooLong               = errors.New("request target exceeds maximum URL length")
+	ErrInvalidContentLength     = errors.New("invalid content length")
+	ErrMissingBearerToken       = errors.New("request has no bearer token")
+	ErrMalformedJWT             = errors.New("malformed JWT")
+	ErrMalformedExchange        = errors.New("malformed exchange")
+	ErrStaleRequest             = errors.New("request is too old")
+	ErrNotGRPCWeb               = errors.New("request is not gRPC-Web")
+	ErrMalformedGRPCWebFrame    = errors.New("malformed gRPC-Web frame")
+	ErrInvalidJSONPath          = errors.New("invalid JSON path")
+)
+
+// ParseError reports where in a serialized request deserialization failed.
+type ParseError struct {
+	Offset int
+	Reason string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at offset %d: %v", e.Reason, e.Offset, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
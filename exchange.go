@@ -0,0 +1,87 @@
+package http_serde
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httputil"
+)
+
+// exchangeMagic prefixes serialized exchanges, followed by the big-endian
+// length of the encoded request, the request itself and then the response in
+// the HTTP/1.1 wire format.
+var exchangeMagic = []byte("HSEX")
+
+// SerializeExchange serializes a request along with the response it got, for
+// archiving whole exchanges. The request goes through the configured options
+// and codec, while the response is dumped as is, as codecs encode requests.
+// With WithEncryption, the whole exchange is then encrypted, response
+// included. Both bodies are replaced by equivalent readers so they can still
+// be read afterwards.
+func (s *serde) SerializeExchange(request *http.Request, response *http.Response) ([]byte, error) {
+	if response == nil {
+		return nil, errors.New("serialize called on nil response")
+	}
+	_, wire, _, err := s.render(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := s.activeCodec().Encode(wire)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httputil.DumpResponse(response, true)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(exchangeMagic) + 4 + len(req) + len(resp))
+	buf.Write(exchangeMagic)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(req)))
+	buf.Write(req)
+	buf.Write(resp)
+	return s.sealPayload(buf.Bytes())
+}
+
+// DeserializeExchange deserializes an exchange produced by SerializeExchange.
+// The Request of the returned response is the returned request, and the
+// response body is fully buffered.
+func (s *serde) DeserializeExchange(serialized []byte) (*http.Request, *http.Response, error) {
+	if s.maxInputBytes > 0 && int64(len(serialized)) > s.maxInputBytes {
+		return nil, nil, ErrInputTooLarge
+	}
+	serialized, err := s.openPayload(serialized)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.HasPrefix(serialized, exchangeMagic) || len(serialized) < len(exchangeMagic)+4 {
+		return nil, nil, ErrMalformedExchange
+	}
+	rest := serialized[len(exchangeMagic):]
+	n := binary.BigEndian.Uint32(rest)
+	rest = rest[4:]
+	if uint64(len(rest)) < uint64(n) {
+		return nil, nil, ErrMalformedExchange
+	}
+	wire, err := s.activeCodec().Decode(rest[:n])
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := s.deserializeWire(wire)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(rest[n:])), req)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return req, resp, nil
+}
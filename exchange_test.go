@@ -0,0 +1,137 @@
+package http_serde
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newResponse(status int, body string) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+}
+
+func TestExchange(t *testing.T) {
+	tests := []struct {
+		it     string
+		opts   []Option
+		setup  func(t *testing.T, s SerDe) []byte
+		assert func(t *testing.T, req *http.Request, resp *http.Response, err error)
+	}{
+		{
+			it:   "encrypts the response along with the request",
+			opts: []Option{WithEncryption(bytes.Repeat([]byte("k"), 32)), WithCodec(GzipCodec{})},
+			setup: func(t *testing.T, s SerDe) []byte {
+				resp := newResponse(http.StatusOK, `{"card":"4111-1111"}`)
+				resp.Header.Set("Set-Cookie", "session=SECRET")
+				ser, err := s.SerializeExchange(newRequest(t, http.MethodGet, "http://test.test/cards", ""), resp)
+				require.NoError(t, err)
+				require.NotContains(t, string(ser), "4111-1111")
+				require.NotContains(t, string(ser), "SECRET")
+				require.False(t, bytes.HasPrefix(ser, exchangeMagic))
+				return ser
+			},
+			assert: func(t *testing.T, req *http.Request, resp *http.Response, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "/cards", req.URL.Path)
+				require.Equal(t, "session=SECRET", resp.Header.Get("Set-Cookie"))
+				b, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				require.Equal(t, `{"card":"4111-1111"}`, string(b))
+			},
+		},
+		{
+			it: "round-trips a request with its response",
+			setup: func(t *testing.T, s SerDe) []byte {
+				ser, err := s.SerializeExchange(
+					newRequest(t, http.MethodPost, "http://test.test/orders", `{"sku":"a-1"}`),
+					newResponse(http.StatusCreated, `{"id":1}`),
+				)
+				require.NoError(t, err)
+				return ser
+			},
+			assert: func(t *testing.T, req *http.Request, resp *http.Response, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "/orders", req.URL.Path)
+				b, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, `{"sku":"a-1"}`, string(b))
+
+				require.Equal(t, http.StatusCreated, resp.StatusCode)
+				require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+				b, err = io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				require.Equal(t, `{"id":1}`, string(b))
+				require.Same(t, req, resp.Request)
+			},
+		},
+		{
+			it: "round-trips bodiless responses",
+			setup: func(t *testing.T, s SerDe) []byte {
+				resp := newResponse(http.StatusNoContent, "")
+				resp.Body = http.NoBody
+				ser, err := s.SerializeExchange(newRequest(t, http.MethodDelete, "http://test.test/orders/1", ""), resp)
+				require.NoError(t, err)
+				return ser
+			},
+			assert: func(t *testing.T, req *http.Request, resp *http.Response, err error) {
+				require.NoError(t, err)
+				require.Equal(t, http.MethodDelete, req.Method)
+				require.Equal(t, http.StatusNoContent, resp.StatusCode)
+				require.Same(t, req, resp.Request)
+			},
+		},
+		{
+			it: "returns an error without the exchange prefix",
+			setup: func(t *testing.T, s SerDe) []byte {
+				return serializeAll(t, newRequest(t, http.MethodGet, "http://test.test/test", ""))
+			},
+			assert: func(t *testing.T, req *http.Request, resp *http.Response, err error) {
+				require.ErrorIs(t, err, ErrMalformedExchange)
+				require.Nil(t, req)
+				require.Nil(t, resp)
+			},
+		},
+		{
+			it: "returns an error if the request is truncated",
+			setup: func(t *testing.T, s SerDe) []byte {
+				return []byte("HSEX\x00\x00\x00\x40GET / HTTP/1.1\r\n\r\n")
+			},
+			assert: func(t *testing.T, req *http.Request, resp *http.Response, err error) {
+				require.ErrorIs(t, err, ErrMalformedExchange)
+			},
+		},
+		{
+			it: "returns an error if the response is missing",
+			setup: func(t *testing.T, s SerDe) []byte {
+				return []byte("HSEX\x00\x00\x00\x23GET / HTTP/1.1\r\nHost: test.test\r\n\r\n")
+			},
+			assert: func(t *testing.T, req *http.Request, resp *http.Response, err error) {
+				require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+				require.Nil(t, resp)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			s := New(tt.opts...)
+			req, resp, err := s.DeserializeExchange(tt.setup(t, s))
+			tt.assert(t, req, resp, err)
+		})
+	}
+
+	_, err := New().SerializeExchange(newRequest(t, http.MethodGet, "http://test.test/test", ""), nil)
+	require.Error(t, err)
+}
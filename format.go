@@ -0,0 +1,135 @@
+package http_serde
+
+import "strings"
+
+// Format is the encoding Serialize produces and Deserialize expects.
+type Format int
+
+const (
+	// FormatWire is the HTTP/1.1 wire format.
+	FormatWire Format = iota
+	// FormatProtobuf is the HttpRequest message of proto/http_request.proto.
+	FormatProtobuf
+)
+
+// LineEnding is the line terminator Serialize uses in the request head.
+type LineEnding int
+
+const (
+	// LineEndingCRLF terminates lines with CRLF, as HTTP/1.1 requires.
+	LineEndingCRLF LineEnding = iota
+	// LineEndingLF terminates lines with a bare LF. The output is meant for
+	// humans and tools rather than servers, though Deserialize reads it back.
+	LineEndingLF
+)
+
+func (e LineEnding) eol() string {
+	if e == LineEndingLF {
+		return "\n"
+	}
+	return "\r\n"
+}
+
+// TrailingSlash is how Serialize normalizes a trailing slash in the request
+// path.
+type TrailingSlash int
+
+const (
+	// TrailingSlashKeep leaves paths as they are.
+	TrailingSlashKeep TrailingSlash = iota
+	// TrailingSlashStrip removes trailing slashes, so /foo/ becomes /foo.
+	TrailingSlashStrip
+	// TrailingSlashAdd appends a slash to paths lacking one, so /foo becomes
+	// /foo/.
+	TrailingSlashAdd
+)
+
+// apply normalizes the path of the request target of line. The root path is
+// never changed.
+func (t TrailingSlash) apply(line string) string {
+	method, rest, ok := strings.Cut(line, " ")
+	sep := strings.LastIndexByte(rest, ' ')
+	if !ok || sep < 0 {
+		return line
+	}
+	target, proto := rest[:sep], rest[sep+1:]
+	start := 0
+	if i := strings.Index(target, "://"); i >= 0 && !strings.HasPrefix(target, "/") {
+		start = strings.IndexByte(target[i+3:], '/')
+		if start < 0 {
+			return line
+		}
+		start += i + 3
+	} else if !strings.HasPrefix(target, "/") {
+		return line
+	}
+	end := strings.IndexAny(target[start:], "?#")
+	if end < 0 {
+		end = len(target)
+	} else {
+		end += start
+	}
+	path := target[start:end]
+	switch t {
+	case TrailingSlashStrip:
+		path = strings.TrimRight(path, "/")
+		if path == "" {
+			path = "/"
+		}
+	case TrailingSlashAdd:
+		if !strings.HasSuffix(path, "/") {
+			path += "/"
+		}
+	}
+	return method + " " + target[:start] + path + target[end:] + " " + proto
+}
+
+// activeCodec returns the codec set with WithCodec, or the one of the
+// configured format.
+func (s *serde) activeCodec() Codec {
+	if s.codec != nil {
+		return s.codec
+	}
+	switch s.format {
+	case FormatProtobuf:
+		return ProtobufCodec{}
+	default:
+		return RawCodec{}
+	}
+}
+
+// encode runs the wire format through the active codec, then encrypts the
+// result when WithEncryption is set, so compressing codecs see plaintext.
+func (s *serde) encode(wire []byte) ([]byte, error) {
+	encoded, err := s.activeCodec().Encode(wire)
+	if err != nil {
+		return nil, err
+	}
+	return s.sealPayload(encoded)
+}
+
+func (s *serde) decode(serialized []byte) ([]byte, error) {
+	payload, err := s.openPayload(serialized)
+	if err != nil {
+		return nil, err
+	}
+	return s.activeCodec().Decode(payload)
+}
+
+// sealPayload encrypts payload when WithEncryption is set. Payloads framing
+// an encoded request with more data are sealed whole, so none of it is left
+// in the clear.
+func (s *serde) sealPayload(payload []byte) ([]byte, error) {
+	if s.encryptionKey == nil {
+		return payload, nil
+	}
+	return seal(s.encryptionKey, payload)
+}
+
+// openPayload reverses sealPayload.
+func (s *serde) openPayload(serialized []byte) ([]byte, error) {
+	if s.encryptionKey == nil {
+		return serialized, nil
+	}
+	return open(s.encryptionKey, serialized)
+}
@@ -0,0 +1,108 @@
+package http_serde
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatsRepeatedHeaders(t *testing.T) {
+	formats := []struct {
+		name   string
+		format Format
+	}{
+		{name: "wire", format: FormatWire},
+		{name: "protobuf", format: FormatProtobuf},
+	}
+	for _, f := range formats {
+		t.Run("round-trips repeated headers in order through "+f.name, func(t *testing.T) {
+			req := newRequest(t, http.MethodGet, "http://test.test/test", "")
+			req.Header.Add("X-Custom", "c")
+			req.Header.Add("X-Custom", "a")
+			req.Header.Add("X-Custom", "b")
+			req.Header.Add("X-Forwarded-For", "10.0.0.1")
+			req.Header.Add("X-Forwarded-For", "10.0.0.2")
+
+			s := New(WithFormat(f.format))
+			ser, err := s.Serialize(req)
+			require.NoError(t, err)
+			got, err := s.Deserialize(ser)
+			require.NoError(t, err)
+			require.Equal(t, []string{"c", "a", "b"}, got.Header.Values("X-Custom"))
+			require.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, got.Header.Values("X-Forwarded-For"))
+		})
+	}
+}
+
+func TestTrailingSlash(t *testing.T) {
+	tests := []struct {
+		it       string
+		mode     TrailingSlash
+		line     string
+		expected string
+	}{
+		{
+			it:       "strips trailing slashes",
+			mode:     TrailingSlashStrip,
+			line:     "GET /foo/ HTTP/1.1",
+			expected: "GET /foo HTTP/1.1",
+		},
+		{
+			it:       "strips trailing slashes before the query",
+			mode:     TrailingSlashStrip,
+			line:     "GET /foo//?q=a/ HTTP/1.1",
+			expected: "GET /foo?q=a/ HTTP/1.1",
+		},
+		{
+			it:       "never strips the root path",
+			mode:     TrailingSlashStrip,
+			line:     "GET / HTTP/1.1",
+			expected: "GET / HTTP/1.1",
+		},
+		{
+			it:       "adds trailing slashes",
+			mode:     TrailingSlashAdd,
+			line:     "GET /foo?q=a HTTP/1.1",
+			expected: "GET /foo/?q=a HTTP/1.1",
+		},
+		{
+			it:       "normalizes absolute-form targets",
+			mode:     TrailingSlashStrip,
+			line:     "GET http://test.test/foo/ HTTP/1.1",
+			expected: "GET http://test.test/foo HTTP/1.1",
+		},
+		{
+			it:       "leaves authority-form targets alone",
+			mode:     TrailingSlashAdd,
+			line:     "CONNECT test.test:443 HTTP/1.1",
+			expected: "CONNECT test.test:443 HTTP/1.1",
+		},
+		{
+			it:       "leaves asterisk-form targets alone",
+			mode:     TrailingSlashAdd,
+			line:     "OPTIONS * HTTP/1.1",
+			expected: "OPTIONS * HTTP/1.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.mode.apply(tt.line))
+		})
+	}
+}
+
+func TestSerializeNormalizeTrailingSlash(t *testing.T) {
+	s := New(WithNormalizeTrailingSlash(TrailingSlashStrip))
+	for target, expected := range map[string]string{
+		"http://test.test/foo/": "GET /foo HTTP/1.1\r\n",
+		"http://test.test/":     "GET / HTTP/1.1\r\n",
+	} {
+		req := newRequest(t, http.MethodGet, target, "")
+		ser, err := s.Serialize(req)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(ser), expected), string(ser))
+		require.Equal(t, target, req.URL.String())
+	}
+}
@@ -0,0 +1,83 @@
+package http_serde
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// fragmentMagic starts every fragment header, followed by an 8 byte id shared
+// by all the fragments of a payload, the fragment index and the total number
+// of fragments, both as big-endian uint32.
+var fragmentMagic = []byte("HSFR")
+
+const fragmentHeaderSize = 4 + 8 + 4 + 4
+
+// Fragment splits a serialized request into fragments of at most maxSize
+// bytes, header included, that Reassemble puts back together in any order.
+func Fragment(serialized []byte, maxSize int) ([][]byte, error) {
+	if maxSize <= fragmentHeaderSize {
+		return nil, fmt.Errorf("fragment size must be greater than %d bytes", fragmentHeaderSize)
+	}
+	chunk := maxSize - fragmentHeaderSize
+	total := (len(serialized) + chunk - 1) / chunk
+	if total == 0 {
+		total = 1
+	}
+	sum := sha256.Sum256(serialized)
+	fragments := make([][]byte, 0, total)
+	for i := 0; i < total; i++ {
+		start, end := i*chunk, (i+1)*chunk
+		if end > len(serialized) {
+			end = len(serialized)
+		}
+		fragment := make([]byte, fragmentHeaderSize, fragmentHeaderSize+end-start)
+		copy(fragment, fragmentMagic)
+		copy(fragment[4:], sum[:8])
+		binary.BigEndian.PutUint32(fragment[12:], uint32(i))
+		binary.BigEndian.PutUint32(fragment[16:], uint32(total))
+		fragments = append(fragments, append(fragment, serialized[start:end]...))
+	}
+	return fragments, nil
+}
+
+// Reassemble puts back together the payload split by Fragment. Fragments may
+// come in any order but must all be there, and must share the id and total of
+// the first one. It returns ErrMissingFragment when none are given or one is
+// missing, ErrDuplicateFragment when an index repeats and ErrMalformedFragment
+// for fragments with a bad header, an index out of range or belonging to
+// another payload. Missing and duplicate indexes are part of the error.
+func Reassemble(fragments [][]byte) ([]byte, error) {
+	if len(fragments) == 0 {
+		return nil, ErrMissingFragment
+	}
+	var id []byte
+	var total uint32
+	ordered := map[uint32][]byte{}
+	for _, fragment := range fragments {
+		if len(fragment) < fragmentHeaderSize || !bytes.HasPrefix(fragment, fragmentMagic) {
+			return nil, ErrMalformedFragment
+		}
+		index := binary.BigEndian.Uint32(fragment[12:])
+		if id == nil {
+			id, total = fragment[4:12], binary.BigEndian.Uint32(fragment[16:])
+		}
+		if !bytes.Equal(id, fragment[4:12]) || total != binary.BigEndian.Uint32(fragment[16:]) || index >= total {
+			return nil, ErrMalformedFragment
+		}
+		if _, ok := ordered[index]; ok {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateFragment, index)
+		}
+		ordered[index] = fragment[fragmentHeaderSize:]
+	}
+	var buf bytes.Buffer
+	for i := uint32(0); i < total; i++ {
+		part, ok := ordered[i]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrMissingFragment, i)
+		}
+		buf.Write(part)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,107 @@
+package http_serde
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFragment(t *testing.T) {
+	blob := make([]byte, 3<<20)
+	_, err := rand.Read(blob)
+	require.NoError(t, err)
+
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) [][]byte
+		assert func(t *testing.T, got []byte, err error)
+	}{
+		{
+			it: "reassembles fragments in any order",
+			setup: func(t *testing.T) [][]byte {
+				fragments, err := Fragment(blob, 1<<20)
+				require.NoError(t, err)
+				require.Len(t, fragments, 4)
+				for _, fragment := range fragments {
+					require.LessOrEqual(t, len(fragment), 1<<20)
+				}
+				return [][]byte{fragments[3], fragments[1], fragments[0], fragments[2]}
+			},
+			assert: func(t *testing.T, got []byte, err error) {
+				require.NoError(t, err)
+				require.True(t, bytes.Equal(blob, got))
+			},
+		},
+		{
+			it: "reassembles empty payloads",
+			setup: func(t *testing.T) [][]byte {
+				fragments, err := Fragment(nil, 1<<20)
+				require.NoError(t, err)
+				require.Len(t, fragments, 1)
+				return fragments
+			},
+			assert: func(t *testing.T, got []byte, err error) {
+				require.NoError(t, err)
+				require.Empty(t, got)
+			},
+		},
+		{
+			it: "returns an error if a fragment is missing",
+			setup: func(t *testing.T) [][]byte {
+				fragments, err := Fragment(blob, 1<<20)
+				require.NoError(t, err)
+				return fragments[1:]
+			},
+			assert: func(t *testing.T, got []byte, err error) {
+				require.ErrorIs(t, err, ErrMissingFragment)
+				require.Nil(t, got)
+			},
+		},
+		{
+			it: "returns an error if a fragment is duplicated",
+			setup: func(t *testing.T) [][]byte {
+				fragments, err := Fragment(blob, 1<<20)
+				require.NoError(t, err)
+				return append(fragments, fragments[2])
+			},
+			assert: func(t *testing.T, got []byte, err error) {
+				require.ErrorIs(t, err, ErrDuplicateFragment)
+			},
+		},
+		{
+			it: "returns an error if fragments belong to different payloads",
+			setup: func(t *testing.T) [][]byte {
+				a, err := Fragment(blob, 1<<20)
+				require.NoError(t, err)
+				b, err := Fragment(blob[1:], 1<<20)
+				require.NoError(t, err)
+				return [][]byte{a[0], b[1], a[2], a[3]}
+			},
+			assert: func(t *testing.T, got []byte, err error) {
+				require.ErrorIs(t, err, ErrMalformedFragment)
+			},
+		},
+		{
+			it: "returns an error if a fragment is malformed",
+			setup: func(t *testing.T) [][]byte {
+				return [][]byte{[]byte("INVALID")}
+			},
+			assert: func(t *testing.T, got []byte, err error) {
+				require.ErrorIs(t, err, ErrMalformedFragment)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			got, err := Reassemble(tt.setup(t))
+			tt.assert(t, got, err)
+		})
+	}
+
+	t.Run("returns an error if fragments cannot hold any data", func(t *testing.T) {
+		_, err := Fragment(blob, fragmentHeaderSize)
+		require.Error(t, err)
+	})
+}
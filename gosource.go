@@ -0,0 +1,71 @@
+package http_serde
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SerializeGoSource renders the request as Go source code of a function that
+// rebuilds it, meant to scaffold tests from captured requests.
+func SerializeGoSource(request *http.Request) (string, error) {
+	if request == nil {
+		return "", errors.New("serialize called on nil request")
+	}
+	body, err := (&serde{}).readBody(request)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("func newRequest() (*http.Request, error) {\n")
+	bodyArg := "nil"
+	if len(body) > 0 {
+		fmt.Fprintf(&b, "\tbody := strings.NewReader(%s)\n", strconv.Quote(string(body)))
+		bodyArg = "body"
+	}
+	fmt.Fprintf(&b, "\treq, err := http.NewRequest(%s, %s, %s)\n",
+		strconv.Quote(request.Method), strconv.Quote(absoluteURL(request)), bodyArg)
+	b.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	if request.Host != "" && request.URL != nil && request.Host != request.URL.Host {
+		fmt.Fprintf(&b, "\treq.Host = %s\n", strconv.Quote(request.Host))
+	}
+	keys := make([]string, 0, len(request.Header))
+	for key := range request.Header {
+		if http.CanonicalHeaderKey(key) != "Content-Length" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for i, value := range request.Header[key] {
+			method := "Add"
+			if i == 0 {
+				method = "Set"
+			}
+			fmt.Fprintf(&b, "\treq.Header.%s(%s, %s)\n", method, strconv.Quote(key), strconv.Quote(value))
+		}
+	}
+	b.WriteString("\treturn req, nil\n}\n")
+	return b.String(), nil
+}
+
+// absoluteURL returns the request URL including scheme and host, which
+// deserialized requests only carry in their Host field.
+func absoluteURL(request *http.Request) string {
+	if request.URL == nil {
+		return ""
+	}
+	if request.URL.IsAbs() {
+		return request.URL.String()
+	}
+	u := *request.URL
+	u.Scheme = "http"
+	if request.TLS != nil {
+		u.Scheme = "https"
+	}
+	u.Host = request.Host
+	return u.String()
+}
@@ -0,0 +1,70 @@
+package http_serde
+
+import (
+	"go/parser"
+	"go/token"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializeGoSource(t *testing.T) {
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) *http.Request
+		assert func(t *testing.T, src string, err error)
+	}{
+		{
+			it: "returns an error if http request is nil",
+			setup: func(t *testing.T) *http.Request {
+				return nil
+			},
+			assert: func(t *testing.T, src string, err error) {
+				require.Error(t, err)
+				require.Empty(t, src)
+			},
+		},
+		{
+			it: "generates source rebuilding the request",
+			setup: func(t *testing.T) *http.Request {
+				req := newRequest(t, http.MethodPost, "http://test.test/test?foo=bar", "{\"a\":\"b\"}\n")
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Add("X-Custom", "a")
+				req.Header.Add("X-Custom", "b")
+				return req
+			},
+			assert: func(t *testing.T, src string, err error) {
+				require.NoError(t, err)
+				_, err = parser.ParseFile(token.NewFileSet(), "", "package main\n\n"+src, 0)
+				require.NoError(t, err)
+				require.Contains(t, src, `http.NewRequest("POST", "http://test.test/test?foo=bar", body)`)
+				require.Contains(t, src, `strings.NewReader("{\"a\":\"b\"}\n")`)
+				require.Contains(t, src, `req.Header.Set("Content-Type", "application/json")`)
+				require.Contains(t, src, `req.Header.Set("X-Custom", "a")`)
+				require.Contains(t, src, `req.Header.Add("X-Custom", "b")`)
+			},
+		},
+		{
+			it: "generates source for deserialized requests",
+			setup: func(t *testing.T) *http.Request {
+				req, err := New().Deserialize(serializeAll(t, newRequest(t, http.MethodGet, "http://test.test/test", "")))
+				require.NoError(t, err)
+				return req
+			},
+			assert: func(t *testing.T, src string, err error) {
+				require.NoError(t, err)
+				_, err = parser.ParseFile(token.NewFileSet(), "", "package main\n\n"+src, 0)
+				require.NoError(t, err)
+				require.Contains(t, src, `http.NewRequest("GET", "http://test.test/test", nil)`)
+				require.NotContains(t, src, "Content-Length")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			got, err := SerializeGoSource(tt.setup(t))
+			tt.assert(t, got, err)
+		})
+	}
+}
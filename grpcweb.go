@@ -0,0 +1,54 @@
+package http_serde
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+)
+
+// grpcWebHeaderLen is the length of the prefix of gRPC-Web frames: a flags
+// byte followed by the big-endian length of the message.
+const grpcWebHeaderLen = 5
+
+// GRPCWebFrames deserializes a gRPC-Web request and splits its body into
+// frames. Each frame is returned whole, its first byte holding the flags (the
+// most significant bit marks trailers) and the message following the 5-byte
+// prefix. Bodies of the application/grpc-web-text variant are base64 decoded
+// first. It fails with ErrNotGRPCWeb on other content types and
+// ErrMalformedGRPCWebFrame on truncated frames.
+func GRPCWebFrames(serialized []byte) ([][]byte, error) {
+	req, err := New().Deserialize(serialized)
+	if err != nil {
+		return nil, err
+	}
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "application/grpc-web") {
+		return nil, ErrNotGRPCWeb
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(mediaType, "application/grpc-web-text") {
+		if body, err = base64.StdEncoding.DecodeString(string(body)); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedGRPCWebFrame, err)
+		}
+	}
+	var frames [][]byte
+	for offset := 0; offset < len(body); {
+		if len(body)-offset < grpcWebHeaderLen {
+			return nil, fmt.Errorf("%w: truncated prefix at offset %d", ErrMalformedGRPCWebFrame, offset)
+		}
+		n := uint64(binary.BigEndian.Uint32(body[offset+1:]))
+		end := uint64(offset) + grpcWebHeaderLen + n
+		if end > uint64(len(body)) {
+			return nil, fmt.Errorf("%w: truncated message at offset %d", ErrMalformedGRPCWebFrame, offset)
+		}
+		frames = append(frames, body[offset:end])
+		offset = int(end)
+	}
+	return frames, nil
+}
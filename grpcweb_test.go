@@ -0,0 +1,84 @@
+package http_serde
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGRPCWebFrames(t *testing.T) {
+	message := []byte("\x00\x00\x00\x00\x03\x0a\x01a")
+	trailers := []byte("\x80\x00\x00\x00\x0fgrpc-status:0\r\n")
+	body := string(message) + string(trailers)
+	tests := []struct {
+		it          string
+		contentType string
+		body        string
+		assert      func(t *testing.T, frames [][]byte, err error)
+	}{
+		{
+			it:          "splits the body into frames",
+			contentType: "application/grpc-web+proto",
+			body:        body,
+			assert: func(t *testing.T, frames [][]byte, err error) {
+				require.NoError(t, err)
+				require.Equal(t, [][]byte{message, trailers}, frames)
+				require.Equal(t, byte(0x80), frames[1][0])
+				require.Equal(t, "grpc-status:0\r\n", string(frames[1][5:]))
+			},
+		},
+		{
+			it:          "decodes grpc-web-text bodies",
+			contentType: "application/grpc-web-text",
+			body:        base64.StdEncoding.EncodeToString([]byte(body)),
+			assert: func(t *testing.T, frames [][]byte, err error) {
+				require.NoError(t, err)
+				require.Equal(t, [][]byte{message, trailers}, frames)
+			},
+		},
+		{
+			it:          "returns no frames for empty bodies",
+			contentType: "application/grpc-web",
+			assert: func(t *testing.T, frames [][]byte, err error) {
+				require.NoError(t, err)
+				require.Empty(t, frames)
+			},
+		},
+		{
+			it:          "returns an error for truncated messages",
+			contentType: "application/grpc-web+proto",
+			body:        body[:len(body)-1],
+			assert: func(t *testing.T, frames [][]byte, err error) {
+				require.ErrorIs(t, err, ErrMalformedGRPCWebFrame)
+				require.ErrorContains(t, err, "truncated message at offset 8")
+			},
+		},
+		{
+			it:          "returns an error for truncated prefixes",
+			contentType: "application/grpc-web+proto",
+			body:        string(message) + "\x80\x00",
+			assert: func(t *testing.T, frames [][]byte, err error) {
+				require.ErrorIs(t, err, ErrMalformedGRPCWebFrame)
+			},
+		},
+		{
+			it:          "returns an error for other content types",
+			contentType: "application/json",
+			body:        "{}",
+			assert: func(t *testing.T, frames [][]byte, err error) {
+				require.ErrorIs(t, err, ErrNotGRPCWeb)
+				require.Nil(t, frames)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			req := newRequest(t, http.MethodPost, "http://test.test/pkg.Service/Method", tt.body)
+			req.Header.Set("Content-Type", tt.contentType)
+			frames, err := GRPCWebFrames(serializeAll(t, req))
+			tt.assert(t, frames, err)
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package http_serde
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type headLine struct {
+	offset int
+	text   string
+}
+
+// headLines splits the head of a serialized request into its request line
+// and header lines, recording where each one starts in serialized.
+func headLines(serialized []byte) []headLine {
+	var lines []headLine
+	offset := 0
+	for offset < len(serialized) {
+		end := bytes.IndexByte(serialized[offset:], '\n')
+		next := offset + end + 1
+		if end < 0 {
+			next = len(serialized)
+			end = len(serialized) - offset
+		}
+		text := strings.TrimSuffix(string(serialized[offset:offset+end]), "\r")
+		if text == "" && len(lines) > 0 {
+			break
+		}
+		lines = append(lines, headLine{offset: offset, text: text})
+		offset = next
+	}
+	return lines
+}
+
+// headEnd returns the offset at which the body of a serialized request
+// starts, right after the empty line ending its head.
+func headEnd(serialized []byte) int {
+	lines := headLines(serialized)
+	if len(lines) == 0 {
+		return 0
+	}
+	last := lines[len(lines)-1]
+	offset := last.offset + len(last.text)
+	for i := 0; i < 2 && offset < len(serialized); i++ {
+		if serialized[offset] == '\r' {
+			offset++
+		}
+		if offset < len(serialized) && serialized[offset] == '\n' {
+			offset++
+		}
+	}
+	return offset
+}
+
+// rawHeaderValues scans the header section of a serialized request for the
+// values of the given header, without going through http.ReadRequest which
+// drops some headers (such as Host) from the parsed request.
+func rawHeaderValues(serialized []byte, name string) []string {
+	var values []string
+	lines := headLines(serialized)
+	if len(lines) > 0 {
+		lines = lines[1:]
+	}
+	for _, line := range lines {
+		key, value, ok := strings.Cut(line.text, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			values = append(values, strings.TrimSpace(value))
+		}
+	}
+	return values
+}
+
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isTokenByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isTokenByte(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+// locateParseError finds the first line of the head that http.ReadRequest
+// would reject, so failures can be reported with a position.
+func locateParseError(serialized []byte) (int, string) {
+	lines := headLines(serialized)
+	if len(lines) == 0 {
+		return 0, "empty request"
+	}
+	method, rest, ok1 := strings.Cut(lines[0].text, " ")
+	target, version, ok2 := strings.Cut(rest, " ")
+	switch {
+	case !ok1 || !ok2 || target == "":
+		return lines[0].offset, "malformed request line"
+	case !isToken(method):
+		return lines[0].offset, "invalid method"
+	case !strings.HasPrefix(version, "HTTP/"):
+		return lines[0].offset + len(method) + len(target) + 2, "malformed HTTP version"
+	}
+	for _, line := range lines[1:] {
+		key, _, ok := strings.Cut(line.text, ":")
+		if !ok || !isToken(key) {
+			return line.offset, "malformed header line"
+		}
+	}
+	last := lines[len(lines)-1]
+	return last.offset + len(last.text), "malformed request"
+}
+
+// requestTarget returns the request target of a serialized request, or an
+// empty string if its request line is malformed.
+func requestTarget(serialized []byte) string {
+	lines := headLines(serialized)
+	if len(lines) == 0 {
+		return ""
+	}
+	_, rest, _ := strings.Cut(lines[0].text, " ")
+	target, _, _ := strings.Cut(rest, " ")
+	return target
+}
+
+// checkRequestLine validates the method, target and version of the request
+// line one by one, so a malformed request line is reported with the token at
+// fault rather than the generic error of http.ReadRequest.
+func checkRequestLine(serialized []byte) error {
+	lines := headLines(serialized)
+	if len(lines) == 0 {
+		return nil
+	}
+	line := lines[0]
+	method, rest, ok1 := strings.Cut(line.text, " ")
+	target, version, ok2 := strings.Cut(rest, " ")
+	if !ok1 || !ok2 {
+		return &ParseError{Offset: line.offset, Reason: "malformed request line", Err: fmt.Errorf("expected method, target and version in %q", line.text)}
+	}
+	if !isToken(method) {
+		return &ParseError{Offset: line.offset, Reason: "invalid method", Err: fmt.Errorf("method %q is not a token", method)}
+	}
+	targetOffset := line.offset + len(method) + 1
+	if err := checkRequestTarget(method, target); err != nil {
+		return &ParseError{Offset: targetOffset, Reason: "invalid request target", Err: err}
+	}
+	if _, _, ok := http.ParseHTTPVersion(version); !ok {
+		return &ParseError{Offset: targetOffset + len(target) + 1, Reason: "malformed HTTP version", Err: fmt.Errorf("version %q is not HTTP/x.y", version)}
+	}
+	return nil
+}
+
+// checkRequestLineBytes rejects request lines holding bytes outside of
+// printable ASCII, which only corruption or an attack put there.
+func checkRequestLineBytes(serialized []byte) error {
+	lines := headLines(serialized)
+	if len(lines) == 0 {
+		return nil
+	}
+	line := lines[0]
+	for i := 0; i < len(line.text); i++ {
+		if c := line.text[i]; c < ' ' || c >= 0x7f {
+			return &ParseError{Offset: line.offset + i, Reason: "invalid request line", Err: fmt.Errorf("byte 0x%02x is not printable ASCII", c)}
+		}
+	}
+	return nil
+}
+
+func checkRequestTarget(method, target string) error {
+	if target == "" {
+		return fmt.Errorf("empty request target")
+	}
+	for i := 0; i < len(target); i++ {
+		if target[i] < ' ' || target[i] == 0x7f {
+			return fmt.Errorf("control character in request target %q", target)
+		}
+	}
+	if method == http.MethodConnect && !strings.HasPrefix(target, "/") {
+		if _, err := url.Parse("http://" + target); err != nil {
+			return err
+		}
+		return nil
+	}
+	_, err := url.ParseRequestURI(target)
+	return err
+}
+
+// dropHeaderLines removes from the head of a serialized request the header
+// lines for which drop returns true, leaving everything else byte for byte.
+func dropHeaderLines(serialized []byte, drop func(key, value string) bool) []byte {
+	lines := headLines(serialized)
+	if len(lines) < 2 {
+		return serialized
+	}
+	var out []byte
+	start := lines[1].offset
+	for i, line := range lines[1:] {
+		key, value, _ := strings.Cut(line.text, ":")
+		if !drop(strings.TrimSpace(key), strings.TrimSpace(value)) {
+			continue
+		}
+		if out == nil {
+			out = append(out, serialized[:start]...)
+		}
+		out = append(out, serialized[start:line.offset]...)
+		start = line.offset + len(line.text) + 1
+		if i+2 < len(lines) {
+			start = lines[i+2].offset
+		} else if bytes.HasPrefix(serialized[line.offset+len(line.text):], []byte("\r\n")) {
+			start++
+		}
+	}
+	if out == nil {
+		return serialized
+	}
+	return append(out, serialized[start:]...)
+}
+
+// unfoldHeaderLines joins obsolete line folding (RFC 7230, section 3.2.4),
+// header lines starting with whitespace, to the header they continue with a
+// single space, so every header holds a single line.
+func unfoldHeaderLines(serialized []byte) []byte {
+	lines := headLines(serialized)
+	folded := false
+	for i, line := range lines {
+		if i > 0 && isContinuation(line.text) {
+			folded = true
+			break
+		}
+	}
+	if !folded {
+		return serialized
+	}
+	var buf bytes.Buffer
+	buf.WriteString(lines[0].text)
+	for _, line := range lines[1:] {
+		if isContinuation(line.text) {
+			buf.WriteByte(' ')
+			buf.WriteString(strings.Trim(line.text, " \t"))
+			continue
+		}
+		buf.WriteString("\r\n")
+		buf.WriteString(strings.TrimRight(line.text, " \t"))
+	}
+	buf.WriteString("\r\n\r\n")
+	buf.Write(serialized[headEnd(serialized):])
+	return buf.Bytes()
+}
+
+func isContinuation(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+}
+
+// rewriteRequestLine replaces the request line of a serialized request with
+// the result of fn, keeping its line ending and everything after it.
+func rewriteRequestLine(serialized []byte, fn func(line string) string) []byte {
+	end := bytes.IndexByte(serialized, '\n')
+	if end < 0 {
+		end = len(serialized)
+	}
+	line := string(bytes.TrimSuffix(serialized[:end], []byte("\r")))
+	rewritten := fn(line)
+	if rewritten == line {
+		return serialized
+	}
+	out := make([]byte, 0, len(serialized)-len(line)+len(rewritten))
+	out = append(out, rewritten...)
+	return append(out, serialized[len(line):]...)
+}
+
+// repairQuery percent-encodes the bytes of the request target query that are
+// not allowed there, such as spaces, leaving valid escapes untouched.
+func repairQuery(line string) string {
+	method, rest, ok := strings.Cut(line, " ")
+	sep := strings.LastIndexByte(rest, ' ')
+	if !ok || sep < 0 {
+		return line
+	}
+	target, proto := rest[:sep], rest[sep+1:]
+	path, query, ok := strings.Cut(target, "?")
+	if !ok {
+		return line
+	}
+	var b strings.Builder
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '%' && i+2 < len(query) && isHex(query[i+1]) && isHex(query[i+2]):
+			b.WriteByte(c)
+		case c <= ' ' || c >= 0x7f || strings.IndexByte("%\"<>\\^`{|}", c) >= 0:
+			fmt.Fprintf(&b, "%%%02X", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return method + " " + path + "?" + b.String() + " " + proto
+}
+
+// collapseRequestLine spells a request line split by runs of spaces or tabs,
+// or with a lowercase version, the standard way. Other lines are returned as
+// they are.
+func collapseRequestLine(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return line
+	}
+	if version := strings.ToUpper(fields[2]); strings.HasPrefix(version, "HTTP/") {
+		fields[2] = version
+	}
+	return strings.Join(fields, " ")
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// normalize rewrites quirks http.ReadRequest would reject into their
+// equivalent standard form.
+func (s *serde) normalize(serialized []byte) []byte {
+	if s.unfoldHeaders {
+		serialized = unfoldHeaderLines(serialized)
+	}
+	if s.rawRequestLine {
+		serialized = rewriteRequestLine(serialized, collapseRequestLine)
+	}
+	if s.repairQuery {
+		serialized = rewriteRequestLine(serialized, repairQuery)
+	}
+	// identity was removed from the Transfer-Encoding registry and is rejected
+	// by http.ReadRequest, but it only means the body is sent as is
+	return dropHeaderLines(serialized, func(key, value string) bool {
+		return strings.EqualFold(key, "Transfer-Encoding") && strings.EqualFold(value, "identity")
+	})
+}
@@ -0,0 +1,272 @@
+package http_serde
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawHeaderValues(t *testing.T) {
+	serialized := []byte(strings.Join([]string{
+		"GET /test HTTP/1.1",
+		"Host: test.test",
+		"x-custom:  a ",
+		"X-Custom: b",
+		"",
+		"X-Custom: body",
+	}, "\r\n"))
+	tests := []struct {
+		it       string
+		name     string
+		expected []string
+	}{
+		{
+			it:       "returns the values of a header in order",
+			name:     "X-Custom",
+			expected: []string{"a", "b"},
+		},
+		{
+			it:       "returns headers dropped by http.ReadRequest",
+			name:     "host",
+			expected: []string{"test.test"},
+		},
+		{
+			it:       "returns nil for missing headers",
+			name:     "Accept",
+			expected: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			require.Equal(t, tt.expected, rawHeaderValues(serialized, tt.name))
+		})
+	}
+}
+
+func TestLocateParseError(t *testing.T) {
+	tests := []struct {
+		it             string
+		serialized     string
+		expectedOffset int
+		expectedReason string
+	}{
+		{
+			it:             "locates empty requests",
+			serialized:     "",
+			expectedOffset: 0,
+			expectedReason: "empty request",
+		},
+		{
+			it:             "locates malformed request lines",
+			serialized:     "INVALID\r\n\r\n",
+			expectedOffset: 0,
+			expectedReason: "malformed request line",
+		},
+		{
+			it:             "locates invalid methods",
+			serialized:     "G(T / HTTP/1.1\r\n\r\n",
+			expectedOffset: 0,
+			expectedReason: "invalid method",
+		},
+		{
+			it:             "locates malformed versions",
+			serialized:     "GET /test FTP/1.1\r\n\r\n",
+			expectedOffset: 10,
+			expectedReason: "malformed HTTP version",
+		},
+		{
+			it:             "locates malformed header lines",
+			serialized:     "GET / HTTP/1.1\r\nHost: test.test\r\nBad Key: value\r\n\r\n",
+			expectedOffset: 33,
+			expectedReason: "malformed header line",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			offset, reason := locateParseError([]byte(tt.serialized))
+			require.Equal(t, tt.expectedOffset, offset)
+			require.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
+
+func TestCheckRequestLine(t *testing.T) {
+	tests := []struct {
+		it             string
+		serialized     string
+		expectedOffset int
+		expectedReason string
+	}{
+		{
+			it:         "accepts origin-form targets",
+			serialized: "GET /test?foo=bar HTTP/1.1\r\n\r\n",
+		},
+		{
+			it:         "accepts absolute-form targets",
+			serialized: "GET http://test.test/test HTTP/1.1\r\n\r\n",
+		},
+		{
+			it:         "accepts asterisk-form targets",
+			serialized: "OPTIONS * HTTP/1.1\r\n\r\n",
+		},
+		{
+			it:         "accepts authority-form targets for CONNECT",
+			serialized: "CONNECT test.test:443 HTTP/1.1\r\n\r\n",
+		},
+		{
+			it:         "leaves empty requests to http.ReadRequest",
+			serialized: "",
+		},
+		{
+			it:             "rejects request lines without a version",
+			serialized:     "GET /test\r\n\r\n",
+			expectedOffset: 0,
+			expectedReason: "malformed request line",
+		},
+		{
+			it:             "rejects invalid methods",
+			serialized:     "G(T /test HTTP/1.1\r\n\r\n",
+			expectedOffset: 0,
+			expectedReason: "invalid method",
+		},
+		{
+			it:             "rejects empty targets",
+			serialized:     "GET  HTTP/1.1\r\n\r\n",
+			expectedOffset: 4,
+			expectedReason: "invalid request target",
+		},
+		{
+			it:             "rejects relative targets",
+			serialized:     "GET test HTTP/1.1\r\n\r\n",
+			expectedOffset: 4,
+			expectedReason: "invalid request target",
+		},
+		{
+			it:             "rejects targets with control characters",
+			serialized:     "GET /te\x00st HTTP/1.1\r\n\r\n",
+			expectedOffset: 4,
+			expectedReason: "invalid request target",
+		},
+		{
+			it:             "rejects malformed versions",
+			serialized:     "GET /test HTTP/1\r\n\r\n",
+			expectedOffset: 10,
+			expectedReason: "malformed HTTP version",
+		},
+		{
+			it:             "rejects trailing spaces after the version",
+			serialized:     "GET /test HTTP/1.1 \r\n\r\n",
+			expectedOffset: 10,
+			expectedReason: "malformed HTTP version",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			err := checkRequestLine([]byte(tt.serialized))
+			if tt.expectedReason == "" {
+				require.NoError(t, err)
+				return
+			}
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			require.Equal(t, tt.expectedOffset, parseErr.Offset)
+			require.Equal(t, tt.expectedReason, parseErr.Reason)
+		})
+	}
+}
+
+func TestUnfoldHeaderLines(t *testing.T) {
+	tests := []struct {
+		it         string
+		serialized string
+		expected   string
+	}{
+		{
+			it:         "leaves requests without folding untouched",
+			serialized: "GET / HTTP/1.1\nHost: test.test\n\n",
+			expected:   "GET / HTTP/1.1\nHost: test.test\n\n",
+		},
+		{
+			it:         "joins continuation lines with a single space",
+			serialized: "GET / HTTP/1.1\r\nX-A: one  \r\n \t two \r\n\tthree\r\nX-B: b\r\n\r\nbody\r\n folded",
+			expected:   "GET / HTTP/1.1\r\nX-A: one two three\r\nX-B: b\r\n\r\nbody\r\n folded",
+		},
+		{
+			it:         "unfolds LF terminated heads",
+			serialized: "GET / HTTP/1.1\nX-A: one\n two\n\n",
+			expected:   "GET / HTTP/1.1\r\nX-A: one two\r\n\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			require.Equal(t, tt.expected, string(unfoldHeaderLines([]byte(tt.serialized))))
+		})
+	}
+}
+
+func TestDropHeaderLines(t *testing.T) {
+	dropCustom := func(key, value string) bool {
+		return key == "X-Custom"
+	}
+	tests := []struct {
+		it         string
+		serialized string
+		expected   string
+	}{
+		{
+			it:         "leaves requests without matching headers untouched",
+			serialized: "GET / HTTP/1.1\r\nHost: test.test\r\n\r\nX-Custom: body",
+			expected:   "GET / HTTP/1.1\r\nHost: test.test\r\n\r\nX-Custom: body",
+		},
+		{
+			it:         "drops matching header lines",
+			serialized: "GET / HTTP/1.1\r\nX-Custom: a\r\nHost: test.test\r\nX-Custom: b\r\n\r\nbody",
+			expected:   "GET / HTTP/1.1\r\nHost: test.test\r\n\r\nbody",
+		},
+		{
+			it:         "drops matching header lines ended by LF only",
+			serialized: "GET / HTTP/1.1\nHost: test.test\nX-Custom: a\n\nbody",
+			expected:   "GET / HTTP/1.1\nHost: test.test\n\nbody",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			require.Equal(t, tt.expected, string(dropHeaderLines([]byte(tt.serialized), dropCustom)))
+		})
+	}
+}
+
+func TestRepairQuery(t *testing.T) {
+	tests := []struct {
+		it       string
+		line     string
+		expected string
+	}{
+		{
+			it:       "encodes raw spaces and pipes in the query",
+			line:     "GET /search?q=a b|c HTTP/1.1",
+			expected: "GET /search?q=a%20b%7Cc HTTP/1.1",
+		},
+		{
+			it:       "keeps valid escapes and encodes stray percent signs",
+			line:     "GET /search?q=a%20b&r=100%&s=%zz HTTP/1.1",
+			expected: "GET /search?q=a%20b&r=100%25&s=%25zz HTTP/1.1",
+		},
+		{
+			it:       "leaves targets without a query untouched",
+			line:     "GET /a b HTTP/1.1",
+			expected: "GET /a b HTTP/1.1",
+		},
+		{
+			it:       "leaves malformed request lines untouched",
+			line:     "INVALID",
+			expected: "INVALID",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			require.Equal(t, tt.expected, repairQuery(tt.line))
+		})
+	}
+}
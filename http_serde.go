@@ -3,11 +3,17 @@ package http_serde
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/http/httputil"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 type Serializer interface {
@@ -18,48 +24,679 @@ type Deserializer interface {
 	Deserialize(serialized []byte) (*http.Request, error)
 }
 
+type ReaderDeserializer interface {
+	DeserializeReader(br *bufio.Reader) (*http.Request, error)
+	DeserializeFrom(r io.Reader) (*http.Request, error)
+	DeserializeFromContext(ctx context.Context, r io.Reader) (*http.Request, error)
+}
+
+type LogSerializer interface {
+	SerializeForLog(request *http.Request) ([]byte, error)
+	SerializeWithSummary(request *http.Request) ([]byte, string, error)
+}
+
+type WriterSerializer interface {
+	SerializeTo(w io.Writer, request *http.Request) error
+}
+
+type LazyBodySerializer interface {
+	SerializeLazyBody(request *http.Request, bodyFn func() (io.ReadCloser, error)) ([]byte, error)
+}
+
+type Planner interface {
+	Plan(request *http.Request) (Changes, error)
+}
+
+type MetadataSerDe interface {
+	SerializeWithMetadata(request *http.Request, metadata Metadata) ([]byte, error)
+	DeserializeWithMetadata(serialized []byte) (*http.Request, Metadata, error)
+	SerializeTagged(request *http.Request, tags map[string]string) ([]byte, error)
+	DeserializeTagged(serialized []byte) (*http.Request, map[string]string, error)
+	DeserializeWithContextValues(serialized []byte, keys map[any]string) (*http.Request, error)
+	DeserializeWithFreshness(serialized []byte, maxAge time.Duration) (*http.Request, error)
+	SerializeWithFootprint(request *http.Request) ([]byte, Footprint, error)
+}
+
+type ExchangeSerDe interface {
+	SerializeExchange(request *http.Request, response *http.Response) ([]byte, error)
+	DeserializeExchange(serialized []byte) (*http.Request, *http.Response, error)
+}
+
+type StatsDeserializer interface {
+	DeserializeWithStats(serialized []byte) (*http.Request, Stats, error)
+	DeserializeWithBodyInfo(serialized []byte) (*http.Request, bool, error)
+}
+
 type SerDe interface {
 	Serializer
+	WriterSerializer
+	LazyBodySerializer
+	LogSerializer
 	Deserializer
+	ReaderDeserializer
+	StatsDeserializer
+	Planner
+	MetadataSerDe
+	ExchangeSerDe
 }
 
-type serde struct{}
+type serde struct {
+	headerAllowList       map[string]bool
+	disableAutoDecompress bool
+	validateHost          bool
+	preserveHeaderOrder   bool
+	seekableBody          bool
+	ignoreBodyCloseError  bool
+	logger                Logger
+	format                Format
+	validateHeaderValues  bool
+	repairQuery           bool
+	bodyReadTimeout       time.Duration
+	trimLeadingBlankLines bool
+	placeholderBody       bool
+	bodyPlaceholder       byte
+	strictBodyLength      bool
+	lazyBody              bool
+	normalizeHostPort     bool
+	maxInputBytes         int64
+	dropForbiddenBodies   bool
+	defaultHeaders        http.Header
+	lineEnding            LineEnding
+	rejectEarlyData       bool
+	idempotencyKey        func(*http.Request) string
+	idempotencyHeader     string
+	canonicalContentType  bool
+	urlPatterns           []string
+	maxValuesPerHeader    int
+	codec                 Codec
+	unfoldHeaders         bool
+	anonymizeIPs          bool
+	strictRequestLine     bool
+	captureResponse       bool
+	dedupeHeaderValues    bool
+	preserveHeaderCase    bool
+	encryptionKey         []byte
+	maxURLBytes           int
+	strictContentLength   bool
+	lowercaseHeaders      bool
+	trailingSlash         TrailingSlash
+	clock                 func() time.Time
+	sortParts             bool
+	jsonRedactions        []string
+	stableIDHeader        string
+	rawRequestLine        bool
+}
+
+var mandatoryHeaders = map[string]bool{
+	"Host":           true,
+	"Content-Length": true,
+}
 
-func contentLength(request *http.Request) (int, error) {
+// readBody drains the request body and replaces it with a fresh reader over
+// the same bytes, so the request can still be sent or read afterwards.
+func (s *serde) readBody(request *http.Request) ([]byte, error) {
 	if request.Body == nil || request.Body == http.NoBody {
-		return 0, nil
+		return nil, nil
+	}
+	body, err := s.readAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := request.Body.Close(); err != nil && !s.ignoreBodyCloseError {
+		return nil, err
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// readAll reads r within the configured body read timeout. On timeout r is
+// closed, which unblocks the pending read for most network bodies.
+func (s *serde) readAll(r io.ReadCloser) ([]byte, error) {
+	if s.bodyReadTimeout <= 0 {
+		return io.ReadAll(r)
 	}
-	var buf bytes.Buffer
-	if _, err := buf.ReadFrom(request.Body); err != nil {
-		return 0, err
+	type result struct {
+		body []byte
+		err  error
 	}
-	if err := request.Body.Close(); err != nil {
-		return 0, err
+	read := make(chan result, 1)
+	go func() {
+		body, err := io.ReadAll(r)
+		read <- result{body, err}
+	}()
+	timer := time.NewTimer(s.bodyReadTimeout)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		_ = r.Close()
+		return nil, ErrBodyReadTimeout
+	case res := <-read:
+		return res.body, res.err
 	}
-	request.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
-	return buf.Len(), nil
+}
+
+type seekableBody struct {
+	*bytes.Reader
+}
+
+func (seekableBody) Close() error {
+	return nil
+}
+
+// makeSeekable buffers the request body into a reader that also implements
+// io.ReadSeeker, for consumers that need to re-read it.
+func makeSeekable(req *http.Request) error {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	if err := req.Body.Close(); err != nil {
+		return err
+	}
+	req.Body = seekableBody{bytes.NewReader(body)}
+	return nil
 }
 
 func (s *serde) Serialize(request *http.Request) ([]byte, error) {
+	ser, _, _, err := s.serialize(request)
+	return ser, err
+}
+
+// serialize serializes the request and returns, besides the output, the
+// request in the wire format before encoding and the length of its body.
+func (s *serde) serialize(request *http.Request) ([]byte, []byte, int, error) {
+	_, dump, l, err := s.render(request)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	ser, err := s.encode(dump)
+	return ser, dump, l, err
+}
+
+// render runs every step of serialize but encoding, returning the request as
+// it was dumped, the request in the wire format and the length of its body.
+func (s *serde) render(request *http.Request) (*http.Request, []byte, int, error) {
 	if request == nil {
-		return nil, errors.New("serialize called on nil request")
+		return nil, nil, 0, errors.New("serialize called on nil request")
 	}
-	l, err := contentLength(request)
+	if s.sortParts {
+		var err error
+		if request, err = s.sortMultipartParts(request); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+	if len(s.jsonRedactions) > 0 {
+		var err error
+		if request, err = s.redactJSONBody(request); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+	body, err := s.readBody(request)
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, err
+	}
+	l := len(body)
+	if s.logger != nil {
+		s.debugf("serialize: read %d body bytes", l)
+	}
+	if len(request.TransferEncoding) > 0 {
+		// the body is framed by its transfer encoding, sending a length too
+		// would make the request ambiguous to servers
+		request.Header.Del("Content-Length")
+	} else {
+		request.Header.Set("Content-Length", strconv.Itoa(l))
+	}
+	if s.stableIDHeader != "" {
+		if request, err = s.withStableRequestID(request); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+	out, dump, err := s.dump(request, body, true)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return out, dump, l, nil
+}
+
+// dump renders the request in the wire format, with or without its body,
+// applying the configured output options, and returns the request dumped
+// along. body holds the bytes of the request body already read, if any.
+func (s *serde) dump(request *http.Request, body []byte, withBody bool) (*http.Request, []byte, error) {
+	if s.validateHeaderValues {
+		if err := validateHeaderValues(request.Header); err != nil {
+			return nil, nil, err
+		}
+	}
+	out := request
+	if request.Method == http.MethodConnect && request.RequestURI == "" && request.URL != nil && request.URL.Path == "" {
+		// httputil.DumpRequest would write the path, whereas CONNECT requests
+		// target the authority of the tunnel
+		out = request.WithContext(request.Context())
+		out.RequestURI = request.URL.Host
+	}
+	if s.maxURLBytes > 0 {
+		target := out.RequestURI
+		if target == "" && out.URL != nil {
+			target = out.URL.RequestURI()
+		}
+		if len(target) > s.maxURLBytes {
+			return nil, nil, ErrURLTooLong
+		}
+	}
+	prepared := s.prepare(out, body)
+	dump, err := httputil.DumpRequest(prepared, withBody)
+	if err != nil {
+		return nil, nil, err
+	}
+	return prepared, s.rewrite(request, dump), nil
+}
+
+// validateHeaderValues rejects header values that are not valid UTF-8 or
+// hold control characters, which would otherwise be silently rewritten when
+// dumping the request.
+func validateHeaderValues(header http.Header) error {
+	for key, values := range header {
+		for _, value := range values {
+			if !utf8.ValidString(value) {
+				return fmt.Errorf("%w: %s", ErrInvalidHeaderValue, key)
+			}
+			for i := 0; i < len(value); i++ {
+				if c := value[i]; (c < ' ' && c != '\t') || c == 0x7f {
+					return fmt.Errorf("%w: %s", ErrInvalidHeaderValue, key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// prepare returns the request to be dumped, cloning it whenever an option
+// needs to alter what is emitted so the caller's request is left untouched.
+// The clone reads body, when given, rather than the reader it would share
+// with the request, as dumping it drains its body.
+func (s *serde) prepare(request *http.Request, body []byte) *http.Request {
+	if !s.transformsRequest() {
+		return request
+	}
+	out := request.Clone(request.Context())
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if s.idempotencyKey != nil && out.Header.Get(s.idempotencyHeader) == "" {
+		out.Header.Set(s.idempotencyHeader, s.idempotencyKey(keyView(out)))
+	}
+	if s.headerAllowList != nil {
+		for key := range out.Header {
+			canonical := http.CanonicalHeaderKey(key)
+			if !s.headerAllowList[canonical] && !mandatoryHeaders[canonical] {
+				delete(out.Header, key)
+			}
+		}
+	}
+	if s.placeholderBody && out.Body != nil && out.Body != http.NoBody {
+		l, _ := strconv.Atoi(out.Header.Get("Content-Length"))
+		out.Body = io.NopCloser(bytes.NewReader(bytes.Repeat([]byte{s.bodyPlaceholder}, l)))
+	}
+	if s.canonicalContentType {
+		values := out.Header["Content-Type"]
+		for i, value := range values {
+			values[i] = canonicalContentType(value)
+		}
+	}
+	if s.dedupeHeaderValues {
+		for key, values := range out.Header {
+			out.Header[key] = dedupe(values)
+		}
+	}
+	if s.anonymizeIPs {
+		anonymizeIPHeaders(out.Header)
+	}
+	if s.normalizeHostPort {
+		scheme := requestScheme(out)
+		out.Host = stripDefaultPort(out.Host, scheme)
+		if out.URL != nil {
+			out.URL.Host = stripDefaultPort(out.URL.Host, scheme)
+		}
+		out.RequestURI = stripRequestURIPort(out.RequestURI)
+	}
+	return out
+}
+
+func (s *serde) transformsRequest() bool {
+	return s.headerAllowList != nil || s.placeholderBody || s.normalizeHostPort || s.idempotencyKey != nil ||
+		s.canonicalContentType || s.anonymizeIPs || s.dedupeHeaderValues
+}
+
+// dedupe drops the values equal to an earlier one, keeping the order in which
+// values first occur.
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := values[:0]
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+// canonicalContentType lowercases the media type, parameter names and the
+// charset, and spaces and orders parameters consistently. Other parameter
+// values, such as a multipart boundary, are kept exactly. Values that cannot
+// be parsed are returned as is.
+func canonicalContentType(value string) string {
+	mediaType, params, err := mime.ParseMediaType(value)
+	if err != nil {
+		return value
+	}
+	if charset, ok := params["charset"]; ok {
+		params["charset"] = strings.ToLower(charset)
+	}
+	if canonical := mime.FormatMediaType(mediaType, params); canonical != "" {
+		return canonical
+	}
+	return value
+}
+
+// keyView returns a copy of out for idempotency key generators, with a body
+// of its own they can read without draining the one about to be dumped.
+func keyView(out *http.Request) *http.Request {
+	view := out.Clone(out.Context())
+	body, _ := (&serde{}).readBody(out)
+	if body != nil {
+		view.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return view
+}
+
+// requestScheme returns the scheme of the request URL, falling back to the
+// connection for server requests, which carry no scheme.
+func requestScheme(request *http.Request) string {
+	if request.URL != nil && request.URL.Scheme != "" {
+		return strings.ToLower(request.URL.Scheme)
+	}
+	if request.TLS != nil {
+		return "https"
 	}
-	request.Header.Set("Content-Length", strconv.Itoa(l))
-	return httputil.DumpRequest(request, true)
+	return "http"
+}
+
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// stripRequestURIPort strips the default port from the authority of an
+// absolute-form request target, as received by proxies, which
+// httputil.DumpRequest writes as it is. Other targets are returned as they
+// are.
+func stripRequestURIPort(requestURI string) string {
+	if strings.HasPrefix(requestURI, "/") {
+		return requestURI
+	}
+	scheme, rest, ok := strings.Cut(requestURI, "://")
+	if !ok {
+		return requestURI
+	}
+	end := strings.IndexAny(rest, "/?#")
+	if end < 0 {
+		end = len(rest)
+	}
+	return scheme + "://" + stripDefaultPort(rest[:end], strings.ToLower(scheme)) + rest[end:]
+}
+
+func stripDefaultPort(host, scheme string) string {
+	if port, ok := defaultPorts[scheme]; ok {
+		return strings.TrimSuffix(host, ":"+port)
+	}
+	return host
 }
 
 func (s *serde) Deserialize(serialized []byte) (*http.Request, error) {
-	req, err := http.ReadRequest(bufio.NewReader(bytes.NewBuffer(serialized)))
+	if s.maxInputBytes > 0 && int64(len(serialized)) > s.maxInputBytes {
+		return nil, ErrInputTooLarge
+	}
+	wire, err := s.decode(serialized)
+	if err != nil {
+		return nil, err
+	}
+	return s.deserializeWire(wire)
+}
+
+func (s *serde) deserializeWire(serialized []byte) (*http.Request, error) {
+	if s.logger != nil {
+		s.debugf("deserialize: parsing %d bytes", len(serialized))
+	}
+	skipped := 0
+	if s.trimLeadingBlankLines {
+		trimmed := bytes.TrimLeft(serialized, " \t\r\n")
+		skipped, serialized = len(serialized)-len(trimmed), trimmed
+	}
+	normalized := s.normalize(serialized)
+	if s.maxURLBytes > 0 && len(requestTarget(normalized)) > s.maxURLBytes {
+		if s.logger != nil {
+			s.debugf("deserialize: %v", ErrURLTooLong)
+		}
+		return nil, ErrURLTooLong
+	}
+	var err error
+	if s.strictRequestLine {
+		err = checkRequestLineBytes(normalized)
+	}
+	if err == nil {
+		err = checkRequestLine(normalized)
+	}
+	if err == nil && s.strictContentLength {
+		err = checkContentLength(normalized)
+	}
 	if err != nil {
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			parseErr.Offset += skipped
+		}
+		if s.logger != nil {
+			s.debugf("deserialize: %v", err)
+		}
+		return nil, err
+	}
+	br := bufio.NewReader(bytes.NewBuffer(normalized))
+	req, err := http.ReadRequest(br)
+	if err != nil {
+		offset, reason := locateParseError(serialized)
+		offset += skipped
+		if s.logger != nil {
+			s.debugf("deserialize: %s at offset %d: %v", reason, offset, err)
+		}
+		return nil, &ParseError{Offset: offset, Reason: reason, Err: err}
+	}
+	if s.logger != nil {
+		s.debugf("deserialize: request line %s %s %s", req.Method, req.RequestURI, req.Proto)
+		s.debugf("deserialize: %d header keys seen", len(req.Header))
+		s.debugf("deserialize: body length %d, transfer encoding %v", req.ContentLength, req.TransferEncoding)
+	}
+	if err := s.validate(normalized, req); err != nil {
 		return nil, err
 	}
+	if s.strictBodyLength && req.ContentLength >= 0 {
+		if err := checkBodyLength(req, br); err != nil {
+			return nil, err
+		}
+	}
+	if s.lazyBody {
+		lazyBody(req, normalized)
+	}
+	s.tune(req)
+	if s.seekableBody {
+		if err := makeSeekable(req); err != nil {
+			return nil, err
+		}
+	}
+	if s.preserveHeaderOrder {
+		req = withHeaderOrder(req, normalized)
+	}
+	if s.preserveHeaderCase {
+		req = withHeaderCase(req, normalized)
+	}
+	if s.rawRequestLine {
+		req = withRawRequestLine(req, serialized)
+	}
 	return req, nil
 }
 
-func New() SerDe {
-	return &serde{}
+func (s *serde) validate(serialized []byte, req *http.Request) error {
+	if s.validateHost && req.URL.Host != "" {
+		// http.ReadRequest prefers the absolute target over the Host header,
+		// so a disagreement between both would otherwise go unnoticed
+		for _, host := range rawHeaderValues(serialized, "Host") {
+			if !strings.EqualFold(host, req.URL.Host) {
+				return ErrHostMismatch
+			}
+		}
+	}
+	if s.rejectEarlyData && req.Header.Get("Early-Data") == "1" {
+		return ErrEarlyData
+	}
+	if s.maxValuesPerHeader > 0 {
+		for key, values := range req.Header {
+			if len(values) > s.maxValuesPerHeader {
+				return fmt.Errorf("%w: %d values for %s", ErrTooManyHeaderValues, len(values), key)
+			}
+		}
+	}
+	return nil
+}
+
+// checkContentLength rejects Content-Length headers that are not a
+// non-negative decimal integer.
+func checkContentLength(serialized []byte) error {
+	for _, value := range rawHeaderValues(serialized, "Content-Length") {
+		if _, err := strconv.ParseUint(value, 10, 63); err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidContentLength, value)
+		}
+	}
+	return nil
+}
+
+// checkBodyLength reads the body of req and makes sure it is neither shorter
+// nor longer than its Content-Length, leaving nothing unread in rest.
+func checkBodyLength(req *http.Request, rest *bufio.Reader) error {
+	body, err := io.ReadAll(req.Body)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrBodyLengthMismatch
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := rest.Peek(1); err == nil {
+		return ErrBodyLengthMismatch
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return nil
+}
+
+// lazyBody replaces the body of req with a reader over the body bytes of
+// serialized, so reading it does not copy them through a buffered reader.
+// Chunked and truncated bodies are left as they are.
+func lazyBody(req *http.Request, serialized []byte) {
+	if req.ContentLength <= 0 || len(req.TransferEncoding) > 0 {
+		return
+	}
+	rest := serialized[headEnd(serialized):]
+	if int64(len(rest)) < req.ContentLength {
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(rest[:req.ContentLength]))
+}
+
+func (s *serde) DeserializeFrom(r io.Reader) (*http.Request, error) {
+	serialized, err := s.readInput(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.Deserialize(serialized)
+}
+
+// readInput reads r to the end, giving up with ErrInputTooLarge as soon as
+// more than the configured maximum input size has been read.
+func (s *serde) readInput(r io.Reader) ([]byte, error) {
+	if s.maxInputBytes <= 0 {
+		return io.ReadAll(r)
+	}
+	serialized, err := io.ReadAll(io.LimitReader(r, s.maxInputBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(serialized)) > s.maxInputBytes {
+		return nil, ErrInputTooLarge
+	}
+	return serialized, nil
+}
+
+// DeserializeFromContext is like DeserializeFrom but gives up as soon as ctx
+// is done. A reader blocked on a read is left behind until it returns.
+func (s *serde) DeserializeFromContext(ctx context.Context, r io.Reader) (*http.Request, error) {
+	type result struct {
+		serialized []byte
+		err        error
+	}
+	read := make(chan result, 1)
+	go func() {
+		serialized, err := s.readInput(r)
+		read <- result{serialized, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-read:
+		if res.err != nil {
+			return nil, res.err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return s.Deserialize(res.serialized)
+	}
+}
+
+// tune adjusts a deserialized request so it behaves as configured once it is
+// sent through an http.Client.
+func (s *serde) tune(req *http.Request) {
+	if s.disableAutoDecompress {
+		// an explicit Accept-Encoding stops http.Transport from asking for
+		// gzip and transparently decompressing the response
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+	for key, values := range s.defaultHeaders {
+		if _, ok := req.Header[key]; !ok {
+			req.Header[key] = append([]string(nil), values...)
+		}
+	}
+	if s.dropForbiddenBodies && bodilessMethods[req.Method] {
+		req.Body = http.NoBody
+		req.ContentLength = 0
+		req.TransferEncoding = nil
+		req.Header.Del("Content-Length")
+	}
+}
+
+// bodilessMethods are the methods whose requests are not expected to carry a
+// body, either per spec (TRACE) or by convention.
+var bodilessMethods = map[string]bool{
+	http.MethodGet:   true,
+	http.MethodHead:  true,
+	http.MethodTrace: true,
+}
+
+// now returns the current time from the clock set with WithClock.
+func (s *serde) now() time.Time {
+	if s.clock != nil {
+		return s.clock()
+	}
+	return time.Now()
+}
+
+func New(opts ...Option) SerDe {
+	s := &serde{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
@@ -0,0 +1,117 @@
+package http_serde
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type received struct {
+	method, requestURI, host string
+	header                   http.Header
+	body                     string
+}
+
+// sendRaw writes serialized to a real http.Server and returns the request as
+// the server parsed it.
+func sendRaw(t *testing.T, serialized []byte) (received, int) {
+	got := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		got <- received{r.Method, r.RequestURI, r.Host, r.Header, string(b)}
+	}))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write(serialized)
+	require.NoError(t, err)
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return received{}, res.StatusCode
+	}
+	return <-got, res.StatusCode
+}
+
+func TestSerializeInterop(t *testing.T) {
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) *http.Request
+		assert func(t *testing.T, got received)
+	}{
+		{
+			it: "serializes GET requests a server can parse",
+			setup: func(t *testing.T) *http.Request {
+				req := newRequest(t, http.MethodGet, "http://test.test/test?foo=bar", "")
+				req.Header.Add("X-Custom", "a")
+				req.Header.Add("X-Custom", "b")
+				return req
+			},
+			assert: func(t *testing.T, got received) {
+				require.Equal(t, http.MethodGet, got.method)
+				require.Equal(t, "/test?foo=bar", got.requestURI)
+				require.Equal(t, "test.test", got.host)
+				require.Equal(t, []string{"a", "b"}, got.header.Values("X-Custom"))
+				require.Empty(t, got.body)
+			},
+		},
+		{
+			it: "serializes POST requests with a body a server can parse",
+			setup: func(t *testing.T) *http.Request {
+				req := newRequest(t, http.MethodPost, "http://test.test/test", `{"a":"b"}`)
+				req.Header.Set("Content-Type", "application/json")
+				return req
+			},
+			assert: func(t *testing.T, got received) {
+				require.Equal(t, http.MethodPost, got.method)
+				require.Equal(t, "application/json", got.header.Get("Content-Type"))
+				require.Equal(t, "9", got.header.Get("Content-Length"))
+				require.Equal(t, `{"a":"b"}`, got.body)
+			},
+		},
+		{
+			it: "serializes chunked requests a server can parse",
+			setup: func(t *testing.T) *http.Request {
+				req := newRequest(t, http.MethodPost, "http://test.test/test", "test")
+				req.TransferEncoding = []string{"chunked"}
+				return req
+			},
+			assert: func(t *testing.T, got received) {
+				// a sender must not send Content-Length along with
+				// Transfer-Encoding, see RFC 7230 section 3.3.2
+				require.Equal(t, http.MethodPost, got.method)
+				require.Empty(t, got.header.Get("Content-Length"))
+				require.Equal(t, "test", got.body)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			req := tt.setup(t)
+			ser, err := New().Serialize(req)
+			require.NoError(t, err)
+			if len(req.TransferEncoding) > 0 {
+				require.NotContains(t, string(ser), "Content-Length")
+			}
+			got, status := sendRaw(t, ser)
+			require.Equal(t, http.StatusOK, status, string(ser))
+			tt.assert(t, got)
+
+			deserialized, err := New().Deserialize(ser)
+			require.NoError(t, err)
+			b, err := io.ReadAll(deserialized.Body)
+			require.NoError(t, err)
+			require.True(t, bytes.Equal([]byte(got.body), b))
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package http_serde
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// BearerClaims decodes the claims of the JWT sent in the Authorization header
+// of a serialized request, reading only the head of the request. The token
+// signature is not verified, so the claims must not be trusted for anything
+// but inspection. It fails with ErrMissingBearerToken when the request carries
+// no bearer token and ErrMalformedJWT when the token is not a JWT.
+func BearerClaims(serialized []byte) (map[string]any, error) {
+	var token string
+	for _, value := range rawHeaderValues(serialized, "Authorization") {
+		scheme, credentials, ok := strings.Cut(value, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			token = strings.TrimSpace(credentials)
+			break
+		}
+	}
+	if token == "" {
+		return nil, ErrMissingBearerToken
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts, got %d", ErrMalformedJWT, len(parts))
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedJWT, err)
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedJWT, err)
+	}
+	return claims, nil
+}
@@ -0,0 +1,88 @@
+package http_serde
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Kind tells apart the messages DetectKind finds in serialized bytes.
+type Kind int
+
+const (
+	// KindUnknown is for payloads that hold neither, or that cannot be
+	// unwrapped, such as encrypted payloads and exchanges.
+	KindUnknown Kind = iota
+	// KindRequest is an HTTP/1.x request, as written by Serialize.
+	KindRequest
+	// KindResponse is an HTTP/1.x response, as written by httputil.DumpResponse.
+	KindResponse
+)
+
+// gzipMagic starts every gzip stream, as written by GzipCodec.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// detectKindHeadBytes bounds how much of a compressed payload DetectKind
+// decompresses to find its first line.
+const detectKindHeadBytes = 8 << 10
+
+// DetectKind reports whether serialized holds a request or a response in the
+// wire format, from its first line alone: method, target and version for a
+// request, version, status code and reason for a response. Payloads prefixed
+// with metadata, compressed with GzipCodec or encoded with JSONCodec are
+// unwrapped first. Anything else, such as an encrypted payload or an
+// exchange, is KindUnknown. Errors are only returned for payloads that claim
+// a framing they do not follow, such as a truncated gzip header.
+func DetectKind(serialized []byte) (Kind, error) {
+	ser, _, err := splitMetadata(serialized)
+	if err != nil {
+		return KindUnknown, err
+	}
+	if bytes.HasPrefix(ser, gzipMagic) {
+		r, err := gzip.NewReader(bytes.NewReader(ser))
+		if err != nil {
+			return KindUnknown, err
+		}
+		defer r.Close()
+		head, err := io.ReadAll(io.LimitReader(r, detectKindHeadBytes))
+		if err != nil && len(head) == 0 {
+			return KindUnknown, err
+		}
+		return detectWireKind(head), nil
+	}
+	if trimmed := bytes.TrimSpace(ser); bytes.HasPrefix(trimmed, []byte("{")) {
+		var r structuredRequest
+		if json.Unmarshal(trimmed, &r) == nil && isToken(r.Method) {
+			return KindRequest, nil
+		}
+		return KindUnknown, nil
+	}
+	return detectWireKind(ser), nil
+}
+
+func detectWireKind(wire []byte) Kind {
+	line := bytes.TrimLeft(wire, "\r\n")
+	if end := bytes.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+	fields := strings.SplitN(strings.TrimSuffix(string(line), "\r"), " ", 3)
+	if len(fields) < 2 {
+		return KindUnknown
+	}
+	if _, _, ok := http.ParseHTTPVersion(fields[0]); ok {
+		if code, err := strconv.Atoi(fields[1]); err == nil && len(fields[1]) == 3 && code >= 100 {
+			return KindResponse
+		}
+		return KindUnknown
+	}
+	if len(fields) == 3 && isToken(fields[0]) && fields[1] != "" {
+		if _, _, ok := http.ParseHTTPVersion(fields[2]); ok {
+			return KindRequest
+		}
+	}
+	return KindUnknown
+}
@@ -0,0 +1,126 @@
+package http_serde
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectKind(t *testing.T) {
+	serializeWith := func(t *testing.T, opts ...Option) []byte {
+		ser, err := New(opts...).Serialize(newRequest(t, http.MethodPost, "http://test.test/test", "test"))
+		require.NoError(t, err)
+		return ser
+	}
+	dumpResponse := func(t *testing.T) []byte {
+		dump, err := httputil.DumpResponse(newResponse(http.StatusCreated, `{"id":1}`), true)
+		require.NoError(t, err)
+		return dump
+	}
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) []byte
+		assert func(t *testing.T, kind Kind, err error)
+	}{
+		{
+			it: "detects requests",
+			setup: func(t *testing.T) []byte {
+				return serializeWith(t)
+			},
+			assert: func(t *testing.T, kind Kind, err error) {
+				require.NoError(t, err)
+				require.Equal(t, KindRequest, kind)
+			},
+		},
+		{
+			it:    "detects responses",
+			setup: dumpResponse,
+			assert: func(t *testing.T, kind Kind, err error) {
+				require.NoError(t, err)
+				require.Equal(t, KindResponse, kind)
+			},
+		},
+		{
+			it: "sniffs gzip compressed responses",
+			setup: func(t *testing.T) []byte {
+				gz, err := GzipCodec{}.Encode(dumpResponse(t))
+				require.NoError(t, err)
+				return gz
+			},
+			assert: func(t *testing.T, kind Kind, err error) {
+				require.NoError(t, err)
+				require.Equal(t, KindResponse, kind)
+			},
+		},
+		{
+			it: "sniffs gzip compressed requests",
+			setup: func(t *testing.T) []byte {
+				return serializeWith(t, WithCodec(GzipCodec{}))
+			},
+			assert: func(t *testing.T, kind Kind, err error) {
+				require.NoError(t, err)
+				require.Equal(t, KindRequest, kind)
+			},
+		},
+		{
+			it: "detects JSON encoded requests",
+			setup: func(t *testing.T) []byte {
+				return serializeWith(t, WithCodec(JSONCodec{}))
+			},
+			assert: func(t *testing.T, kind Kind, err error) {
+				require.NoError(t, err)
+				require.Equal(t, KindRequest, kind)
+			},
+		},
+		{
+			it: "detects requests whose method starts with the metadata magic",
+			setup: func(t *testing.T) []byte {
+				return serializeAll(t, newRequest(t, "HSMDX", "http://test.test/test", ""))
+			},
+			assert: func(t *testing.T, kind Kind, err error) {
+				require.NoError(t, err)
+				require.Equal(t, KindRequest, kind)
+			},
+		},
+		{
+			it: "looks past metadata",
+			setup: func(t *testing.T) []byte {
+				ser, err := New().SerializeTagged(newRequest(t, http.MethodGet, "http://test.test/test", ""), map[string]string{"a": "b"})
+				require.NoError(t, err)
+				return ser
+			},
+			assert: func(t *testing.T, kind Kind, err error) {
+				require.NoError(t, err)
+				require.Equal(t, KindRequest, kind)
+			},
+		},
+		{
+			it: "reports anything else as unknown",
+			setup: func(t *testing.T) []byte {
+				return []byte("HTTP/1.1 OK\r\n\r\n")
+			},
+			assert: func(t *testing.T, kind Kind, err error) {
+				require.NoError(t, err)
+				require.Equal(t, KindUnknown, kind)
+			},
+		},
+		{
+			it: "fails on broken gzip headers",
+			setup: func(t *testing.T) []byte {
+				return []byte{0x1f, 0x8b, 0x08}
+			},
+			assert: func(t *testing.T, kind Kind, err error) {
+				require.Error(t, err)
+				require.Equal(t, KindUnknown, kind)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			kind, err := DetectKind(tt.setup(t))
+			tt.assert(t, kind, err)
+		})
+	}
+}
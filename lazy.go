@@ -0,0 +1,30 @@
+package http_serde
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// SerializeLazyBody serializes the request with the body returned by bodyFn,
+// called once at serialize time, in place of its own. It suits bodies that are
+// generated on demand, where the request Body is nil. The request itself is
+// left untouched.
+func (s *serde) SerializeLazyBody(request *http.Request, bodyFn func() (io.ReadCloser, error)) ([]byte, error) {
+	if request == nil {
+		return nil, errors.New("serialize called on nil request")
+	}
+	if bodyFn == nil {
+		return nil, errors.New("serialize called with nil body function")
+	}
+	body, err := bodyFn()
+	if err != nil {
+		return nil, err
+	}
+	out := request.Clone(request.Context())
+	out.Body = body
+	if body == nil {
+		out.Body = http.NoBody
+	}
+	return s.Serialize(out)
+}
@@ -0,0 +1,76 @@
+package http_serde
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializeLazyBody(t *testing.T) {
+	tests := []struct {
+		it     string
+		bodyFn func() (io.ReadCloser, error)
+		assert func(t *testing.T, b []byte, err error)
+	}{
+		{
+			it: "serializes the body returned by the function",
+			bodyFn: func() (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader("generated")), nil
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.Equal(t, strings.Join([]string{
+					"POST /test HTTP/1.1",
+					"Host: test.test",
+					"Content-Length: 9",
+					"",
+					"generated",
+				}, "\r\n"), string(b))
+			},
+		},
+		{
+			it: "serializes an empty body when the function returns none",
+			bodyFn: func() (io.ReadCloser, error) {
+				return nil, nil
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.True(t, strings.HasSuffix(string(b), "Content-Length: 0\r\n\r\n"))
+			},
+		},
+		{
+			it: "returns the error of the function",
+			bodyFn: func() (io.ReadCloser, error) {
+				return nil, errors.New("boom")
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.EqualError(t, err, "boom")
+				require.Nil(t, b)
+			},
+		},
+		{
+			it: "returns an error without a function",
+			assert: func(t *testing.T, b []byte, err error) {
+				require.Error(t, err)
+				require.Nil(t, b)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "http://test.test/test", nil)
+			require.NoError(t, err)
+			got, err := New().SerializeLazyBody(req, tt.bodyFn)
+			tt.assert(t, got, err)
+			require.Nil(t, req.Body)
+			require.Empty(t, req.Header)
+		})
+	}
+
+	_, err := New().SerializeLazyBody(nil, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package http_serde
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WriteLine serializes the request and writes it to w as a single base64
+// encoded line.
+func WriteLine(w io.Writer, req *http.Request) error {
+	line, err := encodeLine(req, "")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(line)
+	return err
+}
+
+func encodeLine(req *http.Request, prefix string) ([]byte, error) {
+	ser, err := New().Serialize(req)
+	if err != nil {
+		return nil, err
+	}
+	line := make([]byte, len(prefix)+base64.StdEncoding.EncodedLen(len(ser))+1)
+	copy(line, prefix)
+	base64.StdEncoding.Encode(line[len(prefix):], ser)
+	line[len(line)-1] = '\n'
+	return line, nil
+}
+
+// ReadLine reads a request written by WriteLine. It returns io.EOF once there
+// are no more lines to read.
+func ReadLine(r *bufio.Reader) (*http.Request, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return nil, err
+	}
+	ser, err := base64.StdEncoding.DecodeString(strings.TrimRight(line, "\r\n"))
+	if err != nil {
+		return nil, err
+	}
+	return New().Deserialize(ser)
+}
+
+// WriteTimestampedLine is like WriteLine but prefixes the line with at, so
+// the time each request was captured can be recovered, for instance to
+// replay them with their original spacing.
+func WriteTimestampedLine(w io.Writer, req *http.Request, at time.Time) error {
+	line, err := encodeLine(req, at.UTC().Format(time.RFC3339Nano)+" ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(line)
+	return err
+}
+
+// ReadTimestampedLine reads a request written by WriteTimestampedLine along
+// with its timestamp. It returns io.EOF once there are no more lines to read.
+func ReadTimestampedLine(r *bufio.Reader) (*http.Request, time.Time, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return nil, time.Time{}, err
+	}
+	stamp, encoded, ok := strings.Cut(line, " ")
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("missing timestamp in line %q", strings.TrimRight(line, "\r\n"))
+	}
+	at, err := time.Parse(time.RFC3339Nano, stamp)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req, err := ReadLine(bufio.NewReader(strings.NewReader(encoded)))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return req, at, nil
+}
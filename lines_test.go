@@ -0,0 +1,128 @@
+package http_serde
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLine(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteLine(&buf, newRequest(t, http.MethodPost, "http://test.test/a", "a\nb")))
+	require.NoError(t, WriteLine(&buf, newRequest(t, http.MethodGet, "http://test.test/b", "")))
+	require.Error(t, WriteLine(&buf, nil))
+	require.Equal(t, 2, strings.Count(buf.String(), "\n"))
+
+	r := bufio.NewReader(&buf)
+	first, err := ReadLine(r)
+	require.NoError(t, err)
+	require.Equal(t, "/a", first.URL.Path)
+	b, err := io.ReadAll(first.Body)
+	require.NoError(t, err)
+	require.Equal(t, "a\nb", string(b))
+
+	second, err := ReadLine(r)
+	require.NoError(t, err)
+	require.Equal(t, http.MethodGet, second.Method)
+	require.Equal(t, "/b", second.URL.Path)
+
+	_, err = ReadLine(r)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestReadLine(t *testing.T) {
+	tests := []struct {
+		it     string
+		input  string
+		assert func(t *testing.T, req *http.Request, err error)
+	}{
+		{
+			it:    "returns an error if the line is not base64",
+			input: "INVALID!\n",
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.Error(t, err)
+				require.Nil(t, req)
+			},
+		},
+		{
+			it:    "reads a last line without a trailing newline",
+			input: "R0VUIC90ZXN0IEhUVFAvMS4xDQpIb3N0OiB0ZXN0LnRlc3QNCg0K",
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "/test", req.URL.Path)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			got, err := ReadLine(bufio.NewReader(strings.NewReader(tt.input)))
+			tt.assert(t, got, err)
+		})
+	}
+}
+
+func TestWriteTimestampedLine(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 0, 0, 500, time.FixedZone("X", 3600))
+	var buf bytes.Buffer
+	require.NoError(t, WriteTimestampedLine(&buf, newRequest(t, http.MethodPost, "http://test.test/a", "a\nb"), at))
+	require.Error(t, WriteTimestampedLine(&buf, nil, at))
+	require.True(t, strings.HasPrefix(buf.String(), "2024-01-01T11:00:00.0000005Z "))
+	require.Equal(t, 1, strings.Count(buf.String(), "\n"))
+
+	r := bufio.NewReader(&buf)
+	req, got, err := ReadTimestampedLine(r)
+	require.NoError(t, err)
+	require.True(t, at.Equal(got))
+	require.Equal(t, "/a", req.URL.Path)
+	b, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Equal(t, "a\nb", string(b))
+
+	_, _, err = ReadTimestampedLine(r)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestReadTimestampedLine(t *testing.T) {
+	tests := []struct {
+		it     string
+		input  string
+		assert func(t *testing.T, req *http.Request, err error)
+	}{
+		{
+			it:    "returns an error if the line has no timestamp",
+			input: "R0VUIC90ZXN0IEhUVFAvMS4xDQpIb3N0OiB0ZXN0LnRlc3QNCg0K\n",
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.Error(t, err)
+				require.Nil(t, req)
+			},
+		},
+		{
+			it:    "returns an error if the timestamp is invalid",
+			input: "yesterday R0VUIC90ZXN0IEhUVFAvMS4xDQpIb3N0OiB0ZXN0LnRlc3QNCg0K\n",
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.Error(t, err)
+				require.Nil(t, req)
+			},
+		},
+		{
+			it:    "reads a last line without a trailing newline",
+			input: "2024-01-01T00:00:00Z R0VUIC90ZXN0IEhUVFAvMS4xDQpIb3N0OiB0ZXN0LnRlc3QNCg0K",
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "/test", req.URL.Path)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			req, _, err := ReadTimestampedLine(bufio.NewReader(strings.NewReader(tt.input)))
+			tt.assert(t, req, err)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package http_serde
+
+// Logger receives debug traces of the serialization steps. It is satisfied by
+// most logging libraries, either directly or through a small adapter.
+type Logger interface {
+	Debugf(format string, args ...any)
+}
+
+// debugf traces a step to the logger set with WithLogger. Call sites check
+// s.logger first, as boxing the arguments allocates even without a logger.
+func (s *serde) debugf(format string, args ...any) {
+	if s.logger != nil {
+		s.logger.Debugf(format, args...)
+	}
+}
@@ -0,0 +1,67 @@
+package http_serde
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type capturingLogger struct {
+	lines []string
+}
+
+func (l *capturingLogger) Debugf(format string, args ...any) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestWithLogger(t *testing.T) {
+	tests := []struct {
+		it     string
+		run    func(t *testing.T, s SerDe)
+		expect []string
+	}{
+		{
+			it: "traces deserialize steps",
+			run: func(t *testing.T, s SerDe) {
+				_, err := s.Deserialize(serializeAll(t, newRequest(t, http.MethodPost, "http://test.test/test", "test")))
+				require.NoError(t, err)
+			},
+			expect: []string{
+				"deserialize: parsing 63 bytes",
+				"deserialize: request line POST /test HTTP/1.1",
+				"deserialize: 1 header keys seen",
+				"deserialize: body length 4, transfer encoding []",
+			},
+		},
+		{
+			it: "traces deserialize failures",
+			run: func(t *testing.T, s SerDe) {
+				_, err := s.Deserialize([]byte("INVALID\r\n\r\n"))
+				require.Error(t, err)
+			},
+			expect: []string{
+				"deserialize: parsing 11 bytes",
+				`deserialize: malformed request line at offset 0: expected method, target and version in "INVALID"`,
+			},
+		},
+		{
+			it: "traces serialize steps",
+			run: func(t *testing.T, s SerDe) {
+				_, err := s.Serialize(newRequest(t, http.MethodPost, "http://test.test/test", "test"))
+				require.NoError(t, err)
+			},
+			expect: []string{
+				"serialize: read 4 body bytes",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			l := &capturingLogger{}
+			tt.run(t, New(WithLogger(l)))
+			require.Equal(t, tt.expect, l.lines)
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package http_serde
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// metadataMagic prefixes serialized requests carrying metadata, followed by
+// the framing version, the big-endian length of the JSON encoded metadata and
+// the metadata itself. The NUL byte cannot appear in a request line, so the
+// magic is never mistaken for a request whose method starts with HSMD.
+var metadataMagic = []byte("HSMD\x00")
+
+// metadataVersion is the version of the metadata framing.
+const metadataVersion = 1
+
+// Metadata carries facts about a captured request that are not part of its
+// wire format. Cookies is a snapshot of the cookies set by earlier responses
+// in the session the request belongs to, Tags free-form labels used to route
+// and filter captured requests, Response a summary of the redirect response
+// the request was issued for, NegotiatedProtocol the protocol agreed on
+// through TLS ALPN, such as h2 or http/1.1, and CapturedAt when the request
+// was captured. Footprint is set by SerializeWithFootprint.
+type Metadata struct {
+	Hijacked           bool              `json:"hijacked,omitempty"`
+	Cookies            []*http.Cookie    `json:"cookies,omitempty"`
+	Tags               map[string]string `json:"tags,omitempty"`
+	Response           *ResponseSummary  `json:"response,omitempty"`
+	NegotiatedProtocol string            `json:"negotiatedProtocol,omitempty"`
+	CapturedAt         *time.Time        `json:"capturedAt,omitempty"`
+	Footprint          *Footprint        `json:"footprint,omitempty"`
+}
+
+// ResponseSummary is the part of http.Request.Response worth keeping to
+// analyze redirect chains.
+type ResponseSummary struct {
+	StatusCode int    `json:"status"`
+	Location   string `json:"location,omitempty"`
+}
+
+func summarizeResponse(resp *http.Response) *ResponseSummary {
+	return &ResponseSummary{StatusCode: resp.StatusCode, Location: resp.Header.Get("Location")}
+}
+
+// stub rebuilds a response holding only the summarized status and location.
+func (r *ResponseSummary) stub() *http.Response {
+	resp := &http.Response{
+		Status:     fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode: r.StatusCode,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+	}
+	if r.Location != "" {
+		resp.Header.Set("Location", r.Location)
+	}
+	return resp
+}
+
+// SerializeWithMetadata prefixes the serialized request with metadata. A
+// negotiated protocol left empty is taken from the request TLS state. With
+// WithEncryption, the metadata is encrypted along with the request.
+func (s *serde) SerializeWithMetadata(request *http.Request, metadata Metadata) ([]byte, error) {
+	_, wire, _, err := s.render(request)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := s.activeCodec().Encode(wire)
+	if err != nil {
+		return nil, err
+	}
+	framed, _, err := s.prependMetadata(request, metadata, encoded)
+	if err != nil {
+		return nil, err
+	}
+	return s.sealPayload(framed)
+}
+
+// prependMetadata completes metadata from the request and frames it in front
+// of ser, returning the length of the encoded metadata too.
+func (s *serde) prependMetadata(request *http.Request, metadata Metadata, ser []byte) ([]byte, int, error) {
+	if s.captureResponse && metadata.Response == nil && request.Response != nil {
+		metadata.Response = summarizeResponse(request.Response)
+	}
+	if metadata.NegotiatedProtocol == "" && request.TLS != nil {
+		metadata.NegotiatedProtocol = request.TLS.NegotiatedProtocol
+	}
+	md, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, 0, err
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(metadataMagic) + 5 + len(md) + len(ser))
+	buf.Write(metadataMagic)
+	buf.WriteByte(metadataVersion)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(md)))
+	buf.Write(md)
+	buf.Write(ser)
+	return buf.Bytes(), len(md), nil
+}
+
+// DeserializeWithMetadata deserializes requests produced by
+// SerializeWithMetadata. Requests serialized without metadata are accepted
+// too and come back with zero Metadata. When the metadata holds a response
+// summary, the request Response is set to a stub rebuilt from it, and when
+// it holds a negotiated protocol, the request TLS is set to a connection
+// state carrying it.
+func (s *serde) DeserializeWithMetadata(serialized []byte) (*http.Request, Metadata, error) {
+	var metadata Metadata
+	if s.maxInputBytes > 0 && int64(len(serialized)) > s.maxInputBytes {
+		return nil, metadata, ErrInputTooLarge
+	}
+	payload, err := s.openPayload(serialized)
+	if err != nil {
+		return nil, metadata, err
+	}
+	ser, md, err := splitMetadata(payload)
+	if err != nil {
+		return nil, metadata, err
+	}
+	if md != nil {
+		if err := json.Unmarshal(md, &metadata); err != nil {
+			return nil, metadata, err
+		}
+	}
+	wire, err := s.activeCodec().Decode(ser)
+	if err != nil {
+		return nil, metadata, err
+	}
+	req, err := s.deserializeWire(wire)
+	if err != nil {
+		return nil, metadata, err
+	}
+	if metadata.Response != nil {
+		req.Response = metadata.Response.stub()
+	}
+	if metadata.NegotiatedProtocol != "" && req.TLS == nil {
+		req.TLS = &tls.ConnectionState{HandshakeComplete: true, NegotiatedProtocol: metadata.NegotiatedProtocol}
+	}
+	return req, metadata, nil
+}
+
+func splitMetadata(serialized []byte) ([]byte, []byte, error) {
+	if !bytes.HasPrefix(serialized, metadataMagic) {
+		return serialized, nil, nil
+	}
+	rest := serialized[len(metadataMagic):]
+	if len(rest) < 5 || rest[0] != metadataVersion {
+		return nil, nil, ErrMalformedMetadata
+	}
+	rest = rest[1:]
+	n := binary.BigEndian.Uint32(rest)
+	rest = rest[4:]
+	if uint64(len(rest)) < uint64(n) {
+		return nil, nil, ErrMalformedMetadata
+	}
+	return rest[n:], rest[:n], nil
+}
+
+// SerializeTagged serializes the request with tags attached as metadata,
+// leaving its headers alone.
+func (s *serde) SerializeTagged(request *http.Request, tags map[string]string) ([]byte, error) {
+	return s.SerializeWithMetadata(request, Metadata{Tags: tags})
+}
+
+// DeserializeTagged deserializes a request serialized by SerializeTagged and
+// returns its tags.
+func (s *serde) DeserializeTagged(serialized []byte) (*http.Request, map[string]string, error) {
+	req, metadata, err := s.DeserializeWithMetadata(serialized)
+	if err != nil {
+		return nil, nil, err
+	}
+	return req, metadata.Tags, nil
+}
+
+// DeserializeWithContextValues deserializes a request serialized with
+// metadata and places metadata tags into its context: keys maps each context
+// key to the name of the tag holding its value. Tags missing from the
+// metadata leave their key unset.
+func (s *serde) DeserializeWithContextValues(serialized []byte, keys map[any]string) (*http.Request, error) {
+	req, metadata, err := s.DeserializeWithMetadata(serialized)
+	if err != nil {
+		return nil, err
+	}
+	ctx := req.Context()
+	for key, tag := range keys {
+		if value, ok := metadata.Tags[tag]; ok {
+			ctx = context.WithValue(ctx, key, value)
+		}
+	}
+	return req.WithContext(ctx), nil
+}
+
+// DeserializeWithFreshness deserializes a request serialized with metadata,
+// failing with ErrStaleRequest when it was captured more than maxAge ago, as
+// told by the clock set with WithClock, or carries no capture time.
+func (s *serde) DeserializeWithFreshness(serialized []byte, maxAge time.Duration) (*http.Request, error) {
+	req, metadata, err := s.DeserializeWithMetadata(serialized)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.CapturedAt == nil {
+		return nil, fmt.Errorf("%w: no capture time", ErrStaleRequest)
+	}
+	if age := s.now().Sub(*metadata.CapturedAt); age > maxAge {
+		return nil, fmt.Errorf("%w: captured %s ago", ErrStaleRequest, age)
+	}
+	return req, nil
+}
+
+// Footprint is the size breakdown of a request serialized with metadata.
+// Header and body sizes are measured in the wire format, before any codec or
+// encryption runs, so with neither the payload is exactly their sum plus the
+// metadata and its 10 bytes of framing. MetadataBytes is not stored in the
+// metadata, as it depends on it.
+type Footprint struct {
+	HeaderBytes   int `json:"headerBytes"`
+	BodyBytes     int `json:"bodyBytes"`
+	MetadataBytes int `json:"-"`
+}
+
+// SerializeWithFootprint serializes the request with metadata recording its
+// footprint, so the footprint is available again from
+// DeserializeWithMetadata without measuring the request, and returns the
+// footprint measured along the way.
+func (s *serde) SerializeWithFootprint(request *http.Request) ([]byte, Footprint, error) {
+	_, wire, _, err := s.render(request)
+	if err != nil {
+		return nil, Footprint{}, err
+	}
+	encoded, err := s.activeCodec().Encode(wire)
+	if err != nil {
+		return nil, Footprint{}, err
+	}
+	head := headEnd(wire)
+	footprint := Footprint{HeaderBytes: head, BodyBytes: len(wire) - head}
+	framed, n, err := s.prependMetadata(request, Metadata{Footprint: &footprint}, encoded)
+	if err != nil {
+		return nil, Footprint{}, err
+	}
+	footprint.MetadataBytes = n
+	out, err := s.sealPayload(framed)
+	if err != nil {
+		return nil, Footprint{}, err
+	}
+	return out, footprint, nil
+}
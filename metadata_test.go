@@ -0,0 +1,325 @@
+package http_serde
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata(t *testing.T) {
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T, s SerDe) []byte
+		assert func(t *testing.T, req *http.Request, md Metadata, err error)
+	}{
+		{
+			it: "round-trips the hijacked flag",
+			setup: func(t *testing.T, s SerDe) []byte {
+				req := newRequest(t, http.MethodGet, "http://test.test/ws", "")
+				req.Header.Set("Upgrade", "websocket")
+				ser, err := s.SerializeWithMetadata(req, Metadata{Hijacked: true})
+				require.NoError(t, err)
+				return ser
+			},
+			assert: func(t *testing.T, req *http.Request, md Metadata, err error) {
+				require.NoError(t, err)
+				require.True(t, md.Hijacked)
+				require.Equal(t, "/ws", req.URL.Path)
+				require.Equal(t, "websocket", req.Header.Get("Upgrade"))
+			},
+		},
+		{
+			it: "round-trips the session cookie snapshot",
+			setup: func(t *testing.T, s SerDe) []byte {
+				req := newRequest(t, http.MethodGet, "http://test.test/cart", "")
+				req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+				ser, err := s.SerializeWithMetadata(req, Metadata{Cookies: []*http.Cookie{
+					{Name: "session", Value: "abc", Path: "/", Domain: "test.test", HttpOnly: true, Secure: true},
+					{Name: "pref", Value: "", Expires: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), SameSite: http.SameSiteLaxMode},
+				}})
+				require.NoError(t, err)
+				return ser
+			},
+			assert: func(t *testing.T, req *http.Request, md Metadata, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []*http.Cookie{
+					{Name: "session", Value: "abc", Path: "/", Domain: "test.test", HttpOnly: true, Secure: true},
+					{Name: "pref", Value: "", Expires: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), SameSite: http.SameSiteLaxMode},
+				}, md.Cookies)
+				cookie, err := req.Cookie("session")
+				require.NoError(t, err)
+				require.Equal(t, "abc", cookie.Value)
+			},
+		},
+		{
+			it: "defaults to zero metadata",
+			setup: func(t *testing.T, s SerDe) []byte {
+				ser, err := s.SerializeWithMetadata(newRequest(t, http.MethodPost, "http://test.test/test", "test"), Metadata{})
+				require.NoError(t, err)
+				return ser
+			},
+			assert: func(t *testing.T, req *http.Request, md Metadata, err error) {
+				require.NoError(t, err)
+				require.False(t, md.Hijacked)
+				b, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, "test", string(b))
+			},
+		},
+		{
+			it: "deserializes requests serialized without metadata",
+			setup: func(t *testing.T, s SerDe) []byte {
+				return serializeAll(t, newRequest(t, http.MethodGet, "http://test.test/test", ""))
+			},
+			assert: func(t *testing.T, req *http.Request, md Metadata, err error) {
+				require.NoError(t, err)
+				require.Equal(t, Metadata{}, md)
+				require.Equal(t, "/test", req.URL.Path)
+			},
+		},
+		{
+			it: "does not mistake methods starting with the metadata magic for metadata",
+			setup: func(t *testing.T, s SerDe) []byte {
+				return serializeAll(t, newRequest(t, "HSMDX", "http://test.test/test", ""))
+			},
+			assert: func(t *testing.T, req *http.Request, md Metadata, err error) {
+				require.NoError(t, err)
+				require.Equal(t, Metadata{}, md)
+				require.Equal(t, "HSMDX", req.Method)
+				require.Equal(t, "/test", req.URL.Path)
+			},
+		},
+		{
+			it: "returns an error on unknown framing versions",
+			setup: func(t *testing.T, s SerDe) []byte {
+				return []byte("HSMD\x00\x02\x00\x00\x00\x02{}GET / HTTP/1.1\r\n\r\n")
+			},
+			assert: func(t *testing.T, req *http.Request, md Metadata, err error) {
+				require.ErrorIs(t, err, ErrMalformedMetadata)
+				require.Nil(t, req)
+			},
+		},
+		{
+			it: "returns an error if metadata is truncated",
+			setup: func(t *testing.T, s SerDe) []byte {
+				return []byte("HSMD\x00\x01\x00\x00\x00\x10{}")
+			},
+			assert: func(t *testing.T, req *http.Request, md Metadata, err error) {
+				require.ErrorIs(t, err, ErrMalformedMetadata)
+				require.Nil(t, req)
+			},
+		},
+		{
+			it: "returns an error if metadata is not valid JSON",
+			setup: func(t *testing.T, s SerDe) []byte {
+				return []byte("HSMD\x00\x01\x00\x00\x00\x01{GET / HTTP/1.1\r\n\r\n")
+			},
+			assert: func(t *testing.T, req *http.Request, md Metadata, err error) {
+				require.Error(t, err)
+				require.Nil(t, req)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			s := New()
+			got, md, err := s.DeserializeWithMetadata(tt.setup(t, s))
+			tt.assert(t, got, md, err)
+		})
+	}
+}
+
+func TestTagged(t *testing.T) {
+	s := New()
+	tags := map[string]string{"tenant": "acme", "route": "orders", "empty": ""}
+	req := newRequest(t, http.MethodPost, "http://test.test/test", "test")
+	ser, err := s.SerializeTagged(req, tags)
+	require.NoError(t, err)
+	require.NotContains(t, string(ser), "Tenant")
+
+	got, gotTags, err := s.DeserializeTagged(ser)
+	require.NoError(t, err)
+	require.Equal(t, tags, gotTags)
+	require.Empty(t, got.Header.Get("Tenant"))
+	b, err := io.ReadAll(got.Body)
+	require.NoError(t, err)
+	require.Equal(t, "test", string(b))
+
+	_, gotTags, err = s.DeserializeTagged(serializeAll(t, newRequest(t, http.MethodGet, "http://test.test/test", "")))
+	require.NoError(t, err)
+	require.Empty(t, gotTags)
+
+	_, _, err = s.DeserializeTagged([]byte("INVALID"))
+	require.Error(t, err)
+}
+
+func TestCaptureResponse(t *testing.T) {
+	redirected := func(t *testing.T) *http.Request {
+		req := newRequest(t, http.MethodGet, "http://test.test/new", "")
+		req.Response = &http.Response{
+			StatusCode: http.StatusFound,
+			Header:     http.Header{"Location": {"/new"}},
+		}
+		return req
+	}
+
+	s := New(WithCaptureResponse(true))
+	ser, err := s.SerializeWithMetadata(redirected(t), Metadata{})
+	require.NoError(t, err)
+	req, md, err := s.DeserializeWithMetadata(ser)
+	require.NoError(t, err)
+	require.Equal(t, &ResponseSummary{StatusCode: http.StatusFound, Location: "/new"}, md.Response)
+	require.NotNil(t, req.Response)
+	require.Equal(t, http.StatusFound, req.Response.StatusCode)
+	require.Equal(t, "302 Found", req.Response.Status)
+	require.Equal(t, "/new", req.Response.Header.Get("Location"))
+
+	ser, err = New().SerializeWithMetadata(redirected(t), Metadata{})
+	require.NoError(t, err)
+	req, md, err = s.DeserializeWithMetadata(ser)
+	require.NoError(t, err)
+	require.Nil(t, md.Response)
+	require.Nil(t, req.Response)
+}
+
+type (
+	tenantKey struct{}
+	traceKey  struct{}
+	userKey   struct{}
+)
+
+func TestDeserializeWithContextValues(t *testing.T) {
+	s := New()
+	ser, err := s.SerializeTagged(newRequest(t, http.MethodGet, "http://test.test/test", ""), map[string]string{
+		"tenant": "acme",
+		"trace":  "4bf92f3577b34da6",
+	})
+	require.NoError(t, err)
+	req, err := s.DeserializeWithContextValues(ser, map[any]string{
+		tenantKey{}: "tenant",
+		traceKey{}:  "trace",
+		userKey{}:   "user",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "acme", req.Context().Value(tenantKey{}))
+	require.Equal(t, "4bf92f3577b34da6", req.Context().Value(traceKey{}))
+	require.Nil(t, req.Context().Value(userKey{}))
+	require.Equal(t, "/test", req.URL.Path)
+
+	_, err = s.DeserializeWithContextValues([]byte("INVALID"), nil)
+	require.Error(t, err)
+}
+
+func TestNegotiatedProtocol(t *testing.T) {
+	for _, proto := range []string{"h2", "http/1.1"} {
+		t.Run("round-trips "+proto, func(t *testing.T) {
+			s := New()
+			req := newRequest(t, http.MethodGet, "https://test.test/test", "")
+			req.TLS = &tls.ConnectionState{HandshakeComplete: true, NegotiatedProtocol: proto}
+			ser, err := s.SerializeWithMetadata(req, Metadata{})
+			require.NoError(t, err)
+			got, md, err := s.DeserializeWithMetadata(ser)
+			require.NoError(t, err)
+			require.Equal(t, proto, md.NegotiatedProtocol)
+			require.NotNil(t, got.TLS)
+			require.Equal(t, proto, got.TLS.NegotiatedProtocol)
+		})
+	}
+
+	t.Run("leaves TLS unset for plain requests", func(t *testing.T) {
+		s := New()
+		ser, err := s.SerializeWithMetadata(newRequest(t, http.MethodGet, "http://test.test/test", ""), Metadata{})
+		require.NoError(t, err)
+		got, md, err := s.DeserializeWithMetadata(ser)
+		require.NoError(t, err)
+		require.Empty(t, md.NegotiatedProtocol)
+		require.Nil(t, got.TLS)
+	})
+}
+
+func TestDeserializeWithFreshness(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		it         string
+		capturedAt *time.Time
+		assert     func(t *testing.T, req *http.Request, err error)
+	}{
+		{
+			it:         "accepts requests captured within the window",
+			capturedAt: timePtr(now.Add(-time.Minute)),
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "/test", req.URL.Path)
+			},
+		},
+		{
+			it:         "rejects requests captured before the window",
+			capturedAt: timePtr(now.Add(-time.Hour)),
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.ErrorIs(t, err, ErrStaleRequest)
+				require.ErrorContains(t, err, "captured 1h0m0s ago")
+				require.Nil(t, req)
+			},
+		},
+		{
+			it: "rejects requests without a capture time",
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.ErrorIs(t, err, ErrStaleRequest)
+				require.Nil(t, req)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			s := New(WithClock(func() time.Time { return now }))
+			ser, err := s.SerializeWithMetadata(newRequest(t, http.MethodGet, "http://test.test/test", ""), Metadata{CapturedAt: tt.capturedAt})
+			require.NoError(t, err)
+			req, err := s.DeserializeWithFreshness(ser, 5*time.Minute)
+			tt.assert(t, req, err)
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestSerializeWithFootprint(t *testing.T) {
+	tests := []struct {
+		it    string
+		setup func(t *testing.T) *http.Request
+	}{
+		{
+			it: "measures requests with a body",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodPost, "http://test.test/test", "test")
+			},
+		},
+		{
+			it: "measures requests without a body",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodGet, "http://test.test/test", "")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			s := New()
+			ser, footprint, err := s.SerializeWithFootprint(tt.setup(t))
+			require.NoError(t, err)
+			require.Equal(t, len(ser)-len(metadataMagic)-5, footprint.HeaderBytes+footprint.BodyBytes+footprint.MetadataBytes)
+
+			wire := serializeAll(t, tt.setup(t))
+			require.Equal(t, len(wire), footprint.HeaderBytes+footprint.BodyBytes)
+			require.Equal(t, int(tt.setup(t).ContentLength), footprint.BodyBytes)
+
+			_, md, err := s.DeserializeWithMetadata(ser)
+			require.NoError(t, err)
+			require.Equal(t, &Footprint{HeaderBytes: footprint.HeaderBytes, BodyBytes: footprint.BodyBytes}, md.Footprint)
+		})
+	}
+}
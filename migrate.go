@@ -0,0 +1,79 @@
+package http_serde
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// structuredVersion is the schema version of the payloads written by
+// JSONCodec and GobCodec. Version 1 payloads predate the version field and lack it.
+const structuredVersion = 2
+
+type migration struct {
+	to int
+	fn func(payload map[string]any) error
+}
+
+var (
+	migrationsMu sync.RWMutex
+	migrations   = map[int]migration{
+		1: {to: 2, fn: func(map[string]any) error { return nil }},
+	}
+)
+
+// RegisterMigration registers fn to upgrade JSONCodec and GobCodec payloads
+// from schema version from to version to. fn gets the payload decoded as a
+// generic JSON object and edits it in place; its version field is updated
+// once fn returns.
+// Deserialize chains migrations until payloads reach the current version.
+// Registering a migration from a version replaces the previous one.
+func RegisterMigration(from, to int, fn func(payload map[string]any) error) {
+	if to <= from {
+		panic(fmt.Sprintf("http_serde: migration from version %d to %d does not upgrade", from, to))
+	}
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+	migrations[from] = migration{to: to, fn: fn}
+}
+
+// unmarshalStructured decodes a JSONCodec payload, migrating it first when it
+// was written with an older schema version.
+func unmarshalStructured(encoded []byte, r *structuredRequest) error {
+	if err := json.Unmarshal(encoded, r); err != nil {
+		return err
+	}
+	if r.Version == structuredVersion {
+		return nil
+	}
+	if r.Version > structuredVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedSchemaVersion, r.Version)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(encoded, &payload); err != nil {
+		return err
+	}
+	version := r.Version
+	if version == 0 {
+		version = 1
+	}
+	for version < structuredVersion {
+		migrationsMu.RLock()
+		m, ok := migrations[version]
+		migrationsMu.RUnlock()
+		if !ok {
+			return fmt.Errorf("%w: no migration from version %d", ErrUnsupportedSchemaVersion, version)
+		}
+		if err := m.fn(payload); err != nil {
+			return fmt.Errorf("migrating from version %d to %d: %w", version, m.to, err)
+		}
+		version = m.to
+		payload["version"] = version
+	}
+	migrated, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	*r = structuredRequest{}
+	return json.Unmarshal(migrated, r)
+}
@@ -0,0 +1,154 @@
+package http_serde
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONCodecMigrations(t *testing.T) {
+	tests := []struct {
+		it        string
+		migration func(payload map[string]any) error
+		payload   string
+		assert    func(t *testing.T, req *http.Request, err error)
+	}{
+		{
+			it:      "deserializes unversioned v1 payloads",
+			payload: `{"method":"GET","target":"/test","proto":"HTTP/1.1","headers":[{"name":"Host","value":"test.test"}]}`,
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "/test", req.URL.Path)
+				require.Equal(t, "test.test", req.Host)
+			},
+		},
+		{
+			it: "runs registered migrations on v1 payloads",
+			migration: func(payload map[string]any) error {
+				payload["target"] = payload["uri"]
+				delete(payload, "uri")
+				return nil
+			},
+			payload: `{"method":"POST","uri":"/orders","proto":"HTTP/1.1","headers":[{"name":"Host","value":"test.test"},{"name":"Content-Length","value":"4"}],"body":"dGVzdA=="}`,
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				require.Equal(t, http.MethodPost, req.Method)
+				require.Equal(t, "/orders", req.URL.Path)
+				b, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, "test", string(b))
+			},
+		},
+		{
+			it: "returns migration errors",
+			migration: func(payload map[string]any) error {
+				return errors.New("boom")
+			},
+			payload: `{"method":"GET","target":"/test","proto":"HTTP/1.1"}`,
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.ErrorContains(t, err, "migrating from version 1 to 2: boom")
+				require.Nil(t, req)
+			},
+		},
+		{
+			it:      "rejects payloads from newer versions",
+			payload: `{"version":3,"method":"GET","target":"/test","proto":"HTTP/1.1"}`,
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.ErrorIs(t, err, ErrUnsupportedSchemaVersion)
+				require.Nil(t, req)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			if tt.migration != nil {
+				previous := migrations[1]
+				RegisterMigration(1, 2, tt.migration)
+				t.Cleanup(func() { RegisterMigration(1, previous.to, previous.fn) })
+			}
+			req, err := New(WithCodec(JSONCodec{})).Deserialize([]byte(tt.payload))
+			tt.assert(t, req, err)
+		})
+	}
+}
+
+func TestJSONCodecStampsVersion(t *testing.T) {
+	ser, err := New(WithCodec(JSONCodec{})).Serialize(newRequest(t, http.MethodGet, "http://test.test/test", ""))
+	require.NoError(t, err)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(ser, &payload))
+	require.Equal(t, float64(structuredVersion), payload["version"])
+}
+
+func TestGobCodecMigrations(t *testing.T) {
+	tests := []struct {
+		it        string
+		migration func(payload map[string]any) error
+		payload   structuredRequest
+		assert    func(t *testing.T, req *http.Request, err error)
+	}{
+		{
+			it:      "deserializes unversioned v1 payloads",
+			payload: structuredRequest{Method: "GET", Target: "/test", Proto: "HTTP/1.1", Headers: []structuredHeader{{Name: "Host", Value: "test.test"}}},
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "/test", req.URL.Path)
+				require.Equal(t, "test.test", req.Host)
+			},
+		},
+		{
+			it: "runs registered migrations on v1 payloads",
+			migration: func(payload map[string]any) error {
+				payload["target"] = "/v2" + payload["target"].(string)
+				return nil
+			},
+			payload: structuredRequest{Method: "POST", Target: "/orders", Proto: "HTTP/1.1", Headers: []structuredHeader{{Name: "Host", Value: "test.test"}, {Name: "Content-Length", Value: "4"}}, Body: []byte("test")},
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "/v2/orders", req.URL.Path)
+				b, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, "test", string(b))
+			},
+		},
+		{
+			it:      "rejects payloads from newer versions",
+			payload: structuredRequest{Version: 3, Method: "GET", Target: "/test", Proto: "HTTP/1.1"},
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.ErrorIs(t, err, ErrUnsupportedSchemaVersion)
+				require.Nil(t, req)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			if tt.migration != nil {
+				previous := migrations[1]
+				RegisterMigration(1, 2, tt.migration)
+				t.Cleanup(func() { RegisterMigration(1, previous.to, previous.fn) })
+			}
+			var buf bytes.Buffer
+			require.NoError(t, gob.NewEncoder(&buf).Encode(tt.payload))
+			req, err := New(WithCodec(GobCodec{})).Deserialize(buf.Bytes())
+			tt.assert(t, req, err)
+		})
+	}
+}
+
+func TestGobCodecStampsVersion(t *testing.T) {
+	ser, err := New(WithCodec(GobCodec{})).Serialize(newRequest(t, http.MethodGet, "http://test.test/test", ""))
+	require.NoError(t, err)
+	var payload structuredRequest
+	require.NoError(t, gob.NewDecoder(bytes.NewReader(ser)).Decode(&payload))
+	require.Equal(t, structuredVersion, payload.Version)
+}
+
+func TestRegisterMigrationPanicsOnDowngrades(t *testing.T) {
+	require.Panics(t, func() { RegisterMigration(2, 1, nil) })
+}
@@ -0,0 +1,222 @@
+package http_serde
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// partRefHeader marks a part of an envelope whose content was moved out, and
+// holds the key it can be found under.
+const partRefHeader = "Http-Serde-Part"
+
+// SerializeMultipartParts serializes a multipart request into an envelope and
+// the content of each of its parts, keyed by form field name, so large parts
+// can be stored apart. The envelope is a serialized request whose parts keep
+// their headers but reference their content by key. Field names found more
+// than once get a #n suffix from the second occurrence on.
+func SerializeMultipartParts(request *http.Request) ([]byte, map[string][]byte, error) {
+	if request == nil {
+		return nil, nil, errors.New("serialize called on nil request")
+	}
+	boundary, err := multipartBoundary(request.Header)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := (&serde{}).readBody(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	parts := map[string][]byte{}
+	envelope, err := rewriteParts(body, boundary, func(i int, part *multipart.Part, header textproto.MIMEHeader) ([]byte, error) {
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		base := part.FormName()
+		if base == "" {
+			base = "part-" + strconv.Itoa(i)
+		}
+		key := base
+		for n := 2; ; n++ {
+			if _, taken := parts[key]; !taken {
+				break
+			}
+			key = base + "#" + strconv.Itoa(n)
+		}
+		parts[key] = content
+		header.Set(partRefHeader, key)
+		return nil, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	out := request.Clone(request.Context())
+	out.Body = io.NopCloser(bytes.NewReader(envelope))
+	out.ContentLength = int64(len(envelope))
+	ser, err := New().Serialize(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ser, parts, nil
+}
+
+// DeserializeMultipartParts reassembles a request serialized by
+// SerializeMultipartParts from its envelope and parts.
+func DeserializeMultipartParts(envelope []byte, parts map[string][]byte) (*http.Request, error) {
+	req, err := New().Deserialize(envelope)
+	if err != nil {
+		return nil, err
+	}
+	boundary, err := multipartBoundary(req.Header)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	body, err = rewriteParts(body, boundary, func(i int, part *multipart.Part, header textproto.MIMEHeader) ([]byte, error) {
+		key := header.Get(partRefHeader)
+		if key == "" {
+			return io.ReadAll(part)
+		}
+		content, ok := parts[key]
+		if !ok {
+			return nil, ErrMissingPart
+		}
+		header.Del(partRefHeader)
+		return content, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return req, nil
+}
+
+func multipartBoundary(header http.Header) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return "", ErrNotMultipart
+	}
+	return params["boundary"], nil
+}
+
+// rewriteParts writes body again with the same boundary, replacing the
+// content of each part with what fn returns. fn may change the part headers
+// it is given.
+func rewriteParts(body []byte, boundary string, fn func(i int, part *multipart.Part, header textproto.MIMEHeader) ([]byte, error)) ([]byte, error) {
+	var buf bytes.Buffer
+	r := multipart.NewReader(bytes.NewReader(body), boundary)
+	w := multipart.NewWriter(&buf)
+	if err := w.SetBoundary(boundary); err != nil {
+		return nil, err
+	}
+	for i := 0; ; i++ {
+		part, err := r.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		header := textproto.MIMEHeader{}
+		for key, values := range part.Header {
+			header[key] = append([]string(nil), values...)
+		}
+		content, err := fn(i, part, header)
+		if err != nil {
+			return nil, err
+		}
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := pw.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sortedPartsBoundary delimits the parts of bodies written by
+// sortMultipartParts.
+const sortedPartsBoundary = "http-serde-sorted-parts"
+
+// sortMultipartParts returns a copy of a multipart request with its parts
+// sorted by form field name and delimited by sortedPartsBoundary. Requests
+// without a multipart body, or with a part containing the boundary, are
+// returned as they are.
+func (s *serde) sortMultipartParts(request *http.Request) (*http.Request, error) {
+	mediaType, params, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return request, nil
+	}
+	body, err := s.readBody(request)
+	if err != nil {
+		return nil, err
+	}
+	type rawPart struct {
+		name    string
+		header  textproto.MIMEHeader
+		content []byte
+	}
+	var parts []rawPart
+	r := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := r.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Contains(content, []byte("--"+sortedPartsBoundary)) {
+			return request, nil
+		}
+		parts = append(parts, rawPart{name: part.FormName(), header: part.Header, content: content})
+	}
+	sort.SliceStable(parts, func(i, j int) bool {
+		return parts[i].name < parts[j].name
+	})
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := w.SetBoundary(sortedPartsBoundary); err != nil {
+		return nil, err
+	}
+	for _, part := range parts {
+		pw, err := w.CreatePart(part.header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := pw.Write(part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	params["boundary"] = sortedPartsBoundary
+	out := request.Clone(request.Context())
+	out.Header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+	out.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
+	out.ContentLength = int64(buf.Len())
+	return out, nil
+}
@@ -0,0 +1,189 @@
+package http_serde
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newMultipartRequest(t *testing.T, file []byte) *http.Request {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	require.NoError(t, w.WriteField("title", "holiday"))
+	fw, err := w.CreateFormFile("photo", "photo.jpg")
+	require.NoError(t, err)
+	_, err = fw.Write(file)
+	require.NoError(t, err)
+	require.NoError(t, w.WriteField("tag", "a"))
+	require.NoError(t, w.WriteField("tag", "b"))
+	require.NoError(t, w.Close())
+	req, err := http.NewRequest(http.MethodPost, "http://test.test/upload", &body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestSerializeMultipartParts(t *testing.T) {
+	file := append([]byte("\xff\xd8binary\r\n--not-a-boundary\r\n"), bytes.Repeat([]byte{0}, 1024)...)
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) *http.Request
+		assert func(t *testing.T, envelope []byte, parts map[string][]byte, err error)
+	}{
+		{
+			it: "returns an error if http request is nil",
+			setup: func(t *testing.T) *http.Request {
+				return nil
+			},
+			assert: func(t *testing.T, envelope []byte, parts map[string][]byte, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			it: "returns an error if the request is not multipart",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodPost, "http://test.test/upload", "test")
+			},
+			assert: func(t *testing.T, envelope []byte, parts map[string][]byte, err error) {
+				require.ErrorIs(t, err, ErrNotMultipart)
+			},
+		},
+		{
+			it: "moves part contents out of the envelope",
+			setup: func(t *testing.T) *http.Request {
+				return newMultipartRequest(t, file)
+			},
+			assert: func(t *testing.T, envelope []byte, parts map[string][]byte, err error) {
+				require.NoError(t, err)
+				require.Equal(t, map[string][]byte{
+					"title": []byte("holiday"),
+					"photo": file,
+					"tag":   []byte("a"),
+					"tag#2": []byte("b"),
+				}, parts)
+				require.NotContains(t, string(envelope), "holiday")
+				require.NotContains(t, string(envelope), "binary")
+				require.Contains(t, string(envelope), `filename="photo.jpg"`)
+
+				req, err := DeserializeMultipartParts(envelope, parts)
+				require.NoError(t, err)
+				require.NoError(t, req.ParseMultipartForm(1<<20))
+				require.Equal(t, "holiday", req.FormValue("title"))
+				require.Equal(t, []string{"a", "b"}, req.MultipartForm.Value["tag"])
+				f, header, err := req.FormFile("photo")
+				require.NoError(t, err)
+				defer f.Close()
+				require.Equal(t, "photo.jpg", header.Filename)
+				got, err := io.ReadAll(f)
+				require.NoError(t, err)
+				require.Equal(t, file, got)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			envelope, parts, err := SerializeMultipartParts(tt.setup(t))
+			tt.assert(t, envelope, parts, err)
+		})
+	}
+}
+
+func TestDeserializeMultipartParts(t *testing.T) {
+	envelope, parts, err := SerializeMultipartParts(newMultipartRequest(t, []byte("file")))
+	require.NoError(t, err)
+
+	delete(parts, "photo")
+	_, err = DeserializeMultipartParts(envelope, parts)
+	require.ErrorIs(t, err, ErrMissingPart)
+
+	_, err = DeserializeMultipartParts([]byte("INVALID"), parts)
+	require.Error(t, err)
+
+	ser, err := New().Serialize(newRequest(t, http.MethodPost, "http://test.test/upload", "test"))
+	require.NoError(t, err)
+	_, err = DeserializeMultipartParts(ser, parts)
+	require.ErrorIs(t, err, ErrNotMultipart)
+}
+
+func TestDeserializeMultipartReader(t *testing.T) {
+	file := bytes.Repeat([]byte("0123456789"), 64*1024)
+	for _, opts := range [][]Option{nil, {WithLazyBody(true)}, {WithSeekableBody(true)}} {
+		s := New(opts...)
+		ser, err := s.Serialize(newMultipartRequest(t, file))
+		require.NoError(t, err)
+		req, err := s.Deserialize(ser)
+		require.NoError(t, err)
+
+		mr, err := req.MultipartReader()
+		require.NoError(t, err)
+		part, err := mr.NextPart()
+		require.NoError(t, err)
+		require.Equal(t, "title", part.FormName())
+		b, err := io.ReadAll(part)
+		require.NoError(t, err)
+		require.Equal(t, "holiday", string(b))
+
+		part, err = mr.NextPart()
+		require.NoError(t, err)
+		require.Equal(t, "photo.jpg", part.FileName())
+		chunk := make([]byte, 10)
+		_, err = io.ReadFull(part, chunk)
+		require.NoError(t, err)
+		require.Equal(t, "0123456789", string(chunk))
+		rest, err := io.ReadAll(part)
+		require.NoError(t, err)
+		require.Equal(t, len(file)-len(chunk), len(rest))
+
+		for _, tag := range []string{"a", "b"} {
+			part, err = mr.NextPart()
+			require.NoError(t, err)
+			b, err = io.ReadAll(part)
+			require.NoError(t, err)
+			require.Equal(t, tag, string(b))
+		}
+		_, err = mr.NextPart()
+		require.ErrorIs(t, err, io.EOF)
+	}
+}
+
+func TestSortMultipartParts(t *testing.T) {
+	newForm := func(t *testing.T, fields ...string) *http.Request {
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		for i := 0; i < len(fields); i += 2 {
+			require.NoError(t, w.WriteField(fields[i], fields[i+1]))
+		}
+		require.NoError(t, w.Close())
+		req, err := http.NewRequest(http.MethodPost, "http://test.test/form", &body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		return req
+	}
+	s := New(WithSortMultipartParts(true))
+	ab, err := s.Serialize(newForm(t, "a", "1", "b", "2"))
+	require.NoError(t, err)
+	ba, err := s.Serialize(newForm(t, "b", "2", "a", "1"))
+	require.NoError(t, err)
+	require.Equal(t, string(ab), string(ba))
+
+	req, err := s.Deserialize(ba)
+	require.NoError(t, err)
+	require.NoError(t, req.ParseMultipartForm(1024))
+	require.Equal(t, "1", req.FormValue("a"))
+	require.Equal(t, "2", req.FormValue("b"))
+
+	dup, err := s.Serialize(newForm(t, "b", "x", "a", "1", "b", "y"))
+	require.NoError(t, err)
+	req, err = s.Deserialize(dup)
+	require.NoError(t, err)
+	require.NoError(t, req.ParseMultipartForm(1024))
+	require.Equal(t, []string{"x", "y"}, req.MultipartForm.Value["b"])
+
+	unsorted, err := New().Serialize(newForm(t, "b", "2", "a", "1"))
+	require.NoError(t, err)
+	require.NotEqual(t, string(ab), string(unsorted))
+}
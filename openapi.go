@@ -0,0 +1,91 @@
+package http_serde
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// openAPIIgnoredHeaders are the header parameters the OpenAPI specification
+// ignores, as they are described by other fields of the operation.
+var openAPIIgnoredHeaders = map[string]bool{
+	"Accept":         true,
+	"Content-Type":   true,
+	"Authorization":  true,
+	"Content-Length": true,
+}
+
+type openAPIParameter struct {
+	Name    string `json:"name"`
+	In      string `json:"in"`
+	Example any    `json:"example"`
+}
+
+type openAPIRequestBody struct {
+	Content map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIMediaType struct {
+	Example any `json:"example"`
+}
+
+type openAPIOperation struct {
+	Parameters  []openAPIParameter  `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody `json:"requestBody,omitempty"`
+}
+
+// SerializeOpenAPIExample renders the request as an OpenAPI paths object
+// holding a single operation, with the query and headers as parameter
+// examples and the body as the request body example. JSON bodies are
+// embedded as JSON, any other body as a string.
+func SerializeOpenAPIExample(request *http.Request) ([]byte, error) {
+	if request == nil {
+		return nil, errors.New("serialize called on nil request")
+	}
+	body, err := (&serde{}).readBody(request)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(absoluteURL(request))
+	if err != nil {
+		return nil, err
+	}
+	var operation openAPIOperation
+	query := u.Query()
+	for _, key := range sortedKeys(query) {
+		operation.Parameters = append(operation.Parameters, openAPIParameter{Name: key, In: "query", Example: parameterExample(query[key])})
+	}
+	for _, key := range sortedKeys(request.Header) {
+		if openAPIIgnoredHeaders[key] {
+			continue
+		}
+		operation.Parameters = append(operation.Parameters, openAPIParameter{Name: key, In: "header", Example: parameterExample(request.Header[key])})
+	}
+	if len(body) > 0 {
+		mediaType := "application/octet-stream"
+		if parsed, _, err := mime.ParseMediaType(request.Header.Get("Content-Type")); err == nil {
+			mediaType = parsed
+		}
+		var example any = string(body)
+		if json.Valid(body) && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) {
+			example = json.RawMessage(body)
+		}
+		operation.RequestBody = &openAPIRequestBody{Content: map[string]openAPIMediaType{mediaType: {Example: example}}}
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	methods := map[string]openAPIOperation{strings.ToLower(request.Method): operation}
+	return json.Marshal(map[string]map[string]openAPIOperation{path: methods})
+}
+
+func parameterExample(values []string) any {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return values
+}
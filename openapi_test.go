@@ -0,0 +1,87 @@
+package http_serde
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializeOpenAPIExample(t *testing.T) {
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) *http.Request
+		assert func(t *testing.T, b []byte, err error)
+	}{
+		{
+			it: "returns an error if request is nil",
+			setup: func(t *testing.T) *http.Request {
+				return nil
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.Error(t, err)
+				require.Nil(t, b)
+			},
+		},
+		{
+			it: "renders the operation with parameters and a JSON body example",
+			setup: func(t *testing.T) *http.Request {
+				req := newRequest(t, http.MethodPost, "http://test.test/api/users?debug=1&tag=a&tag=b", `{"name":"test"}`)
+				req.Header.Set("Content-Type", "application/json; charset=utf-8")
+				req.Header.Set("X-Request-Id", "abc")
+				return req
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.JSONEq(t, `{
+					"/api/users": {
+						"post": {
+							"parameters": [
+								{"name": "debug", "in": "query", "example": "1"},
+								{"name": "tag", "in": "query", "example": ["a", "b"]},
+								{"name": "X-Request-Id", "in": "header", "example": "abc"}
+							],
+							"requestBody": {
+								"content": {
+									"application/json": {"example": {"name": "test"}}
+								}
+							}
+						}
+					}
+				}`, string(b))
+			},
+		},
+		{
+			it: "renders non-JSON bodies as strings",
+			setup: func(t *testing.T) *http.Request {
+				req := newRequest(t, http.MethodPut, "http://test.test/notes", "hello")
+				req.Header.Set("Content-Type", "text/plain")
+				return req
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				var got map[string]map[string]openAPIOperation
+				require.NoError(t, json.Unmarshal(b, &got))
+				require.Equal(t, "hello", got["/notes"]["put"].RequestBody.Content["text/plain"].Example)
+				require.Empty(t, got["/notes"]["put"].Parameters)
+			},
+		},
+		{
+			it: "omits the request body of bodiless requests",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodGet, "http://test.test", "")
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.JSONEq(t, `{"/": {"get": {}}}`, string(b))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			got, err := SerializeOpenAPIExample(tt.setup(t))
+			tt.assert(t, got, err)
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package http_serde
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option configures the SerDe returned by New.
+type Option func(*serde)
+
+// WithHeaderAllowList makes Serialize emit only the given headers, besides
+// Host and Content-Length, leaving the caller's request untouched.
+func WithHeaderAllowList(names ...string) Option {
+	return func(s *serde) {
+		s.headerAllowList = make(map[string]bool, len(names))
+		for _, name := range names {
+			s.headerAllowList[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// WithDisableAutoDecompress makes Deserialize set Accept-Encoding to identity
+// so an http.Client returns response bodies as sent by the server.
+func WithDisableAutoDecompress(disable bool) Option {
+	return func(s *serde) {
+		s.disableAutoDecompress = disable
+	}
+}
+
+// WithValidateHostConsistency makes Deserialize return ErrHostMismatch when an
+// absolute-form request target disagrees with the Host header.
+func WithValidateHostConsistency(validate bool) Option {
+	return func(s *serde) {
+		s.validateHost = validate
+	}
+}
+
+// WithPreserveHeaderOrder makes Deserialize record the original header order
+// and Serialize emit headers in that order when it is known, falling back to
+// sorted keys otherwise.
+func WithPreserveHeaderOrder(preserve bool) Option {
+	return func(s *serde) {
+		s.preserveHeaderOrder = preserve
+	}
+}
+
+// WithSeekableBody makes Deserialize return bodies that also implement
+// io.ReadSeeker.
+func WithSeekableBody(seekable bool) Option {
+	return func(s *serde) {
+		s.seekableBody = seekable
+	}
+}
+
+// WithIgnoreBodyCloseError makes Serialize carry on with the bytes already
+// read when closing the request body fails.
+func WithIgnoreBodyCloseError(ignore bool) Option {
+	return func(s *serde) {
+		s.ignoreBodyCloseError = ignore
+	}
+}
+
+// WithLogger traces the serialization steps to l at debug level.
+func WithLogger(l Logger) Option {
+	return func(s *serde) {
+		s.logger = l
+	}
+}
+
+// WithFormat sets the encoding of serialized requests, FormatWire by default.
+func WithFormat(format Format) Option {
+	return func(s *serde) {
+		s.format = format
+	}
+}
+
+// WithValidateHeaderValues makes Serialize return ErrInvalidHeaderValue for
+// header values holding CR, LF, NUL or other control characters.
+func WithValidateHeaderValues(validate bool) Option {
+	return func(s *serde) {
+		s.validateHeaderValues = validate
+	}
+}
+
+// WithRepairQuery makes Deserialize percent-encode the bytes of the request
+// target query that should have been escaped, such as spaces or pipes.
+func WithRepairQuery(repair bool) Option {
+	return func(s *serde) {
+		s.repairQuery = repair
+	}
+}
+
+// WithBodyReadTimeout makes Serialize return ErrBodyReadTimeout when reading
+// the request body takes longer than d.
+func WithBodyReadTimeout(d time.Duration) Option {
+	return func(s *serde) {
+		s.bodyReadTimeout = d
+	}
+}
+
+// WithTrimLeadingBlankLines makes Deserialize skip blank lines and whitespace
+// found before the request line.
+func WithTrimLeadingBlankLines(trim bool) Option {
+	return func(s *serde) {
+		s.trimLeadingBlankLines = trim
+	}
+}
+
+// WithBodyPlaceholder makes Serialize replace the body with as many copies of
+// placeholder as it has bytes, keeping its length but not its content.
+func WithBodyPlaceholder(placeholder byte) Option {
+	return func(s *serde) {
+		s.placeholderBody = true
+		s.bodyPlaceholder = placeholder
+	}
+}
+
+// WithStrictBodyLength makes Deserialize return ErrBodyLengthMismatch when the
+// body is shorter or longer than the Content-Length header declares.
+func WithStrictBodyLength(strict bool) Option {
+	return func(s *serde) {
+		s.strictBodyLength = strict
+	}
+}
+
+// WithLazyBody makes Deserialize return a body reading straight from the
+// serialized bytes instead of through an intermediate buffer.
+func WithLazyBody(lazy bool) Option {
+	return func(s *serde) {
+		s.lazyBody = lazy
+	}
+}
+
+// WithNormalizeHostPort makes Serialize leave out the port of the host when it
+// is the default one for the scheme, 80 for http and 443 for https.
+func WithNormalizeHostPort(normalize bool) Option {
+	return func(s *serde) {
+		s.normalizeHostPort = normalize
+	}
+}
+
+// WithMaxInputBytes makes Deserialize and DeserializeFrom return
+// ErrInputTooLarge for serialized requests longer than n bytes, before
+// parsing them.
+func WithMaxInputBytes(n int64) Option {
+	return func(s *serde) {
+		s.maxInputBytes = n
+	}
+}
+
+// WithDropForbiddenBodies makes Deserialize discard the body of GET, HEAD and
+// TRACE requests, which are not expected to carry one.
+func WithDropForbiddenBodies(drop bool) Option {
+	return func(s *serde) {
+		s.dropForbiddenBodies = drop
+	}
+}
+
+// WithDefaultHeaders makes Deserialize add the headers in h that the
+// deserialized request lacks, leaving the ones it has untouched.
+func WithDefaultHeaders(h http.Header) Option {
+	return func(s *serde) {
+		s.defaultHeaders = http.Header{}
+		for key, values := range h {
+			s.defaultHeaders[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+	}
+}
+
+// WithLineEnding sets the line terminator of the serialized request head. The
+// body is left as is.
+func WithLineEnding(ending LineEnding) Option {
+	return func(s *serde) {
+		s.lineEnding = ending
+	}
+}
+
+// WithRejectEarlyData makes Deserialize return ErrEarlyData for requests
+// marked with Early-Data: 1, which were sent in TLS 1.3 early data and may
+// have been replayed by an attacker (RFC 8470).
+func WithRejectEarlyData(reject bool) Option {
+	return func(s *serde) {
+		s.rejectEarlyData = reject
+	}
+}
+
+// WithIdempotencyKey makes Serialize set headerName to the key gen derives
+// from the request, unless the request already has one, so services receiving
+// replayed requests can recognize duplicates. gen may read the body of the
+// request it is given.
+func WithIdempotencyKey(gen func(*http.Request) string, headerName string) Option {
+	return func(s *serde) {
+		s.idempotencyKey = gen
+		s.idempotencyHeader = headerName
+	}
+}
+
+// WithCanonicalizeContentType makes Serialize emit Content-Type headers in a
+// canonical spelling, so equivalent values serialize identically. Multipart
+// boundaries are kept as they are.
+func WithCanonicalizeContentType(canonicalize bool) Option {
+	return func(s *serde) {
+		s.canonicalContentType = canonicalize
+	}
+}
+
+// WithURLPatterns sets the route patterns SerializeForLog replaces matching
+// paths with, such as /users/{id}/posts/{id}. Segments in braces match any
+// single path segment, and the first matching pattern wins.
+func WithURLPatterns(patterns []string) Option {
+	return func(s *serde) {
+		s.urlPatterns = append([]string(nil), patterns...)
+	}
+}
+
+// WithMaxValuesPerHeader makes Deserialize return ErrTooManyHeaderValues for
+// requests repeating any header key more than n times.
+func WithMaxValuesPerHeader(n int) Option {
+	return func(s *serde) {
+		s.maxValuesPerHeader = n
+	}
+}
+
+// WithCodec sets the encoding of serialized requests to codec, taking
+// precedence over WithFormat.
+func WithCodec(codec Codec) Option {
+	return func(s *serde) {
+		s.codec = codec
+	}
+}
+
+// WithUnfoldHeaders makes Deserialize join header values continued on lines
+// starting with whitespace (obsolete line folding) into a single line, so
+// every step reading the raw head sees one line per header.
+func WithUnfoldHeaders(unfold bool) Option {
+	return func(s *serde) {
+		s.unfoldHeaders = unfold
+	}
+}
+
+// WithIPAnonymization makes Serialize truncate the addresses in the
+// X-Forwarded-For, X-Real-IP and Forwarded headers, zeroing the last octet of
+// IPv4 addresses and the last 80 bits of IPv6 addresses.
+func WithIPAnonymization(anonymize bool) Option {
+	return func(s *serde) {
+		s.anonymizeIPs = anonymize
+	}
+}
+
+// WithStrictRequestLine makes Deserialize reject request lines holding
+// non-ASCII or control characters with a ParseError pointing at the first
+// offending byte.
+func WithStrictRequestLine(strict bool) Option {
+	return func(s *serde) {
+		s.strictRequestLine = strict
+	}
+}
+
+// WithCaptureResponse makes SerializeWithMetadata record the status and
+// Location of the request Response, set when the request follows a redirect,
+// so DeserializeWithMetadata can restore a stub of it.
+func WithCaptureResponse(capture bool) Option {
+	return func(s *serde) {
+		s.captureResponse = capture
+	}
+}
+
+// WithDedupeHeaderValues makes Serialize drop the values of a header that are
+// identical to an earlier value of the same header, keeping distinct values
+// in the order they first occur. The request itself is left untouched.
+func WithDedupeHeaderValues(dedupe bool) Option {
+	return func(s *serde) {
+		s.dedupeHeaderValues = dedupe
+	}
+}
+
+// WithHeaderCanonicalization(false) makes Deserialize record how header keys
+// were spelled in the serialized request, and Serialize spell them that way
+// again instead of in their canonical form. Keys set directly on
+// http.Request.Header are always serialized as they are.
+func WithHeaderCanonicalization(canonicalize bool) Option {
+	return func(s *serde) {
+		s.preserveHeaderCase = !canonicalize
+	}
+}
+
+// WithEncryption makes Serialize encrypt its output with AES-GCM under key,
+// prefixed by a random nonce, and Deserialize decrypt and authenticate its
+// input, failing with ErrDecryptionFailed on tampering or a wrong key. key
+// must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+// Encryption applies after the codec, so a compressing codec still pays off.
+func WithEncryption(key []byte) Option {
+	return func(s *serde) {
+		s.encryptionKey = append([]byte(nil), key...)
+	}
+}
+
+// WithMaxURLBytes makes Serialize and Deserialize fail with ErrURLTooLong on
+// requests whose request target is longer than n bytes. Zero, the default,
+// means no limit.
+func WithMaxURLBytes(n int) Option {
+	return func(s *serde) {
+		s.maxURLBytes = n
+	}
+}
+
+// WithStrictContentLength makes Deserialize fail with ErrInvalidContentLength
+// on Content-Length headers that are negative or not numeric, instead of the
+// generic parse error of http.ReadRequest.
+func WithStrictContentLength(strict bool) Option {
+	return func(s *serde) {
+		s.strictContentLength = strict
+	}
+}
+
+// WithLowercaseHeaders makes Serialize write header keys in lowercase, as
+// HTTP/2 does, for dumps meant to be replayed over HTTP/2. Deserialize reads
+// header keys regardless of their case.
+func WithLowercaseHeaders(lowercase bool) Option {
+	return func(s *serde) {
+		s.lowercaseHeaders = lowercase
+	}
+}
+
+// WithNormalizeTrailingSlash makes Serialize strip or add a trailing slash to
+// the path of the request target, so /foo and /foo/ serialize alike. The
+// root path is left alone, and so is the request.
+func WithNormalizeTrailingSlash(t TrailingSlash) Option {
+	return func(s *serde) {
+		s.trailingSlash = t
+	}
+}
+
+// WithClock sets the clock telling the current time, time.Now by default,
+// for instance to check freshness against a fixed time in tests.
+func WithClock(now func() time.Time) Option {
+	return func(s *serde) {
+		s.clock = now
+	}
+}
+
+// WithSortMultipartParts makes Serialize write the parts of multipart bodies
+// ordered by form field name, keeping the order of parts sharing a name, and
+// delimited by a fixed boundary, so forms sent with their fields in any order
+// serialize the same.
+func WithSortMultipartParts(sort bool) Option {
+	return func(s *serde) {
+		s.sortParts = sort
+	}
+}
+
+// WithJSONBodyRedaction makes Serialize replace the values found at paths in
+// JSON bodies with a redaction marker, leaving the request itself and bodies
+// of other media types alone. Paths are a subset of JSONPath: $ followed by
+// .name, ['name'], [n] and the wildcards .* and [*], such as $.user.ssn or
+// $.cards[*].number. A body with a redacted value is written again compactly
+// with its object keys sorted.
+func WithJSONBodyRedaction(paths []string) Option {
+	return func(s *serde) {
+		s.jsonRedactions = append([]string(nil), paths...)
+	}
+}
+
+// WithStableRequestID makes Serialize set headerName to the hex encoded
+// SHA-256 of the request as rendered by Canonicalize, so independent captures
+// of equal requests share an ID whatever their header order, query order or
+// body framing. The header itself is left out of the fingerprint, so
+// serializing a request carrying an ID again keeps it.
+func WithStableRequestID(headerName string) Option {
+	return func(s *serde) {
+		s.stableIDHeader = http.CanonicalHeaderKey(headerName)
+	}
+}
+
+// WithRawRequestLine makes Deserialize accept request lines split by runs of
+// spaces or tabs, or with a lowercase version, and record the request line as
+// it was, which Serialize then writes verbatim, quirks included, unless the
+// method, target or version changed in between.
+func WithRawRequestLine(raw bool) Option {
+	return func(s *serde) {
+		s.rawRequestLine = raw
+	}
+}
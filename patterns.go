@@ -0,0 +1,62 @@
+package http_serde
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// SerializeForLog serializes the request like Serialize, but with its path
+// replaced by the first pattern given to WithURLPatterns that matches it, so
+// requests to the same route can be grouped. The output is meant for logs and
+// analytics rather than replay.
+func (s *serde) SerializeForLog(request *http.Request) ([]byte, error) {
+	if request == nil {
+		return nil, errors.New("serialize called on nil request")
+	}
+	if request.URL == nil {
+		return s.Serialize(request)
+	}
+	pattern, ok := matchURLPattern(s.urlPatterns, request.URL.Path)
+	if !ok {
+		return s.Serialize(request)
+	}
+	out := request.WithContext(request.Context())
+	u := *request.URL
+	// an opaque URL keeps the braces of the pattern from being escaped
+	u.Opaque, u.Path, u.RawPath = pattern, pattern, ""
+	out.URL = &u
+	if out.RequestURI != "" {
+		out.RequestURI = u.RequestURI()
+	}
+	ser, err := s.Serialize(out)
+	request.Body = out.Body
+	return ser, err
+}
+
+// matchURLPattern returns the first of patterns matching path, where a
+// pattern segment in braces, such as {id}, matches any single segment.
+func matchURLPattern(patterns []string, path string) (string, bool) {
+	segments := strings.Split(path, "/")
+	for _, pattern := range patterns {
+		patternSegments := strings.Split(pattern, "/")
+		if len(patternSegments) != len(segments) {
+			continue
+		}
+		matches := true
+		for i, segment := range patternSegments {
+			if !isPlaceholder(segment) && segment != segments[i] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+func isPlaceholder(segment string) bool {
+	return len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
@@ -0,0 +1,83 @@
+package http_serde
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializeForLog(t *testing.T) {
+	patterns := []string{"/users/{id}", "/users/{id}/posts/{id}", "/users/me/posts/{id}"}
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) *http.Request
+		assert func(t *testing.T, req *http.Request, b []byte, err error)
+	}{
+		{
+			it: "returns an error if http request is nil",
+			setup: func(t *testing.T) *http.Request {
+				return nil
+			},
+			assert: func(t *testing.T, req *http.Request, b []byte, err error) {
+				require.Error(t, err)
+				require.Nil(t, b)
+			},
+		},
+		{
+			it: "replaces the path with the matching pattern",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodPost, "http://test.test/users/42/posts/7?foo=bar", "test")
+			},
+			assert: func(t *testing.T, req *http.Request, b []byte, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "POST /users/{id}/posts/{id}?foo=bar HTTP/1.1\r\nHost: test.test\r\nContent-Length: 4\r\n\r\ntest", string(b))
+				require.Equal(t, "/users/42/posts/7", req.URL.Path)
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, "test", string(body))
+			},
+		},
+		{
+			it: "uses the first matching pattern",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodGet, "http://test.test/users/me/posts/7", "")
+			},
+			assert: func(t *testing.T, req *http.Request, b []byte, err error) {
+				require.NoError(t, err)
+				require.Contains(t, string(b), "GET /users/{id}/posts/{id} HTTP/1.1\r\n")
+			},
+		},
+		{
+			it: "replaces the path of deserialized requests",
+			setup: func(t *testing.T) *http.Request {
+				req, err := New().Deserialize([]byte("GET /users/42 HTTP/1.1\r\nHost: test.test\r\n\r\n"))
+				require.NoError(t, err)
+				return req
+			},
+			assert: func(t *testing.T, req *http.Request, b []byte, err error) {
+				require.NoError(t, err)
+				require.Contains(t, string(b), "GET /users/{id} HTTP/1.1\r\n")
+				require.Equal(t, "/users/42", req.RequestURI)
+			},
+		},
+		{
+			it: "keeps paths matching no pattern",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodGet, "http://test.test/users/42/likes", "")
+			},
+			assert: func(t *testing.T, req *http.Request, b []byte, err error) {
+				require.NoError(t, err)
+				require.Contains(t, string(b), "GET /users/42/likes HTTP/1.1\r\n")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			req := tt.setup(t)
+			b, err := New(WithURLPatterns(patterns)).SerializeForLog(req)
+			tt.assert(t, req, b, err)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package http_serde
+
+import "bytes"
+
+// PeekHeader returns the first value of the named header of a serialized
+// request in the wire format, scanning its head in place without parsing the
+// request, building its header map or reading its body. The name is matched
+// case-insensitively.
+func PeekHeader(serialized []byte, name string) (string, bool) {
+	rest := serialized
+	first := true
+	for len(rest) > 0 {
+		end := bytes.IndexByte(rest, '\n')
+		line := rest
+		if end >= 0 {
+			line, rest = rest[:end], rest[end+1:]
+		} else {
+			rest = nil
+		}
+		if n := len(line); n > 0 && line[n-1] == '\r' {
+			line = line[:n-1]
+		}
+		if first {
+			first = false
+			continue
+		}
+		if len(line) == 0 {
+			break
+		}
+		if len(line) <= len(name) || line[len(name)] != ':' || !equalFoldASCII(line[:len(name)], name) {
+			continue
+		}
+		return string(bytes.TrimSpace(line[len(name)+1:])), true
+	}
+	return "", false
+}
+
+func equalFoldASCII(b []byte, s string) bool {
+	if len(b) != len(s) {
+		return false
+	}
+	for i := 0; i < len(b); i++ {
+		x, y := b[i], s[i]
+		if 'A' <= x && x <= 'Z' {
+			x += 'a' - 'A'
+		}
+		if 'A' <= y && y <= 'Z' {
+			y += 'a' - 'A'
+		}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package http_serde
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var peekSerialized = []byte(strings.Join([]string{
+	"POST /api/v1/orders HTTP/1.1",
+	"Host: test.test",
+	"Accept: application/json",
+	"X-Tenant-Id:  acme ",
+	"x-route: orders",
+	"X-Custom: a",
+	"X-Custom: b",
+	"Content-Length: 29",
+	"",
+	"X-Not-A-Header: in the body\r\n",
+}, "\r\n"))
+
+func TestPeekHeader(t *testing.T) {
+	tests := []struct {
+		it            string
+		serialized    []byte
+		name          string
+		expected      string
+		expectedFound bool
+	}{
+		{
+			it:            "finds a header value",
+			serialized:    peekSerialized,
+			name:          "X-Tenant-Id",
+			expected:      "acme",
+			expectedFound: true,
+		},
+		{
+			it:            "matches names case-insensitively",
+			serialized:    peekSerialized,
+			name:          "X-Route",
+			expected:      "orders",
+			expectedFound: true,
+		},
+		{
+			it:            "returns the first of repeated values",
+			serialized:    peekSerialized,
+			name:          "x-custom",
+			expected:      "a",
+			expectedFound: true,
+		},
+		{
+			it:         "does not match name prefixes",
+			serialized: peekSerialized,
+			name:       "X-Tenant",
+		},
+		{
+			it:         "stops at the end of the head",
+			serialized: peekSerialized,
+			name:       "X-Not-A-Header",
+		},
+		{
+			it:         "does not match the request line",
+			serialized: []byte("Host: test.test\r\n\r\n"),
+			name:       "Host",
+		},
+		{
+			it:            "reads heads with bare LF line endings",
+			serialized:    []byte("GET / HTTP/1.1\nHost: test.test\n\n"),
+			name:          "Host",
+			expected:      "test.test",
+			expectedFound: true,
+		},
+		{
+			it:         "returns false for empty input",
+			serialized: nil,
+			name:       "Host",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			got, found := PeekHeader(tt.serialized, tt.name)
+			require.Equal(t, tt.expectedFound, found)
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestPeekHeaderAgreesWithDeserialize(t *testing.T) {
+	req, err := New().Deserialize(peekSerialized)
+	require.NoError(t, err)
+	for _, name := range []string{"Accept", "X-Tenant-Id", "X-Route", "X-Custom", "Content-Length"} {
+		got, found := PeekHeader(peekSerialized, name)
+		require.True(t, found)
+		require.Equal(t, req.Header.Get(name), got)
+	}
+}
+
+func BenchmarkPeekHeader(b *testing.B) {
+	benchmarks := []struct {
+		name string
+		peek func() (string, error)
+	}{
+		{
+			name: "PeekHeader",
+			peek: func() (string, error) {
+				value, _ := PeekHeader(peekSerialized, "X-Tenant-Id")
+				return value, nil
+			},
+		},
+		{
+			name: "Deserialize",
+			peek: func() (string, error) {
+				req, err := New().Deserialize(peekSerialized)
+				if err != nil {
+					return "", err
+				}
+				return req.Header.Get("X-Tenant-Id"), nil
+			},
+		},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := bm.peek(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package http_serde
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// Changes describes what Serialize would do to a request and its output.
+type Changes struct {
+	Set           http.Header
+	Removed       []string
+	ContentLength int
+	Size          int
+}
+
+// Plan reports the header changes and output size Serialize would produce for
+// the request, running the same steps on a clone of it so it is left
+// untouched, and failing where Serialize would. The body is read through
+// GetBody when available, otherwise it is drained and replaced by an
+// equivalent reader.
+func (s *serde) Plan(request *http.Request) (Changes, error) {
+	if request == nil {
+		return Changes{}, errors.New("plan called on nil request")
+	}
+	body, err := s.peekBody(request)
+	if err != nil {
+		return Changes{}, err
+	}
+	clone := request.Clone(request.Context())
+	clone.Body = io.NopCloser(bytes.NewReader(body))
+	out, dump, l, err := s.render(clone)
+	if err != nil {
+		return Changes{}, err
+	}
+	encoded, err := s.encode(dump)
+	if err != nil {
+		return Changes{}, err
+	}
+	changes := diffHeaders(request.Header, out.Header)
+	changes.ContentLength = l
+	changes.Size = len(encoded)
+	return changes, nil
+}
+
+func (s *serde) peekBody(request *http.Request) ([]byte, error) {
+	if request.GetBody == nil {
+		return s.readBody(request)
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+func diffHeaders(before, after http.Header) Changes {
+	changes := Changes{Set: http.Header{}}
+	for key, values := range after {
+		if !equalValues(before[key], values) {
+			changes.Set[key] = values
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changes.Removed = append(changes.Removed, key)
+		}
+	}
+	sort.Strings(changes.Removed)
+	return changes
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package http_serde
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		it     string
+		opts   []Option
+		setup  func(t *testing.T) *http.Request
+		assert func(t *testing.T, req *http.Request, changes Changes, err error)
+	}{
+		{
+			it: "returns an error if http request is nil",
+			setup: func(t *testing.T) *http.Request {
+				return nil
+			},
+			assert: func(t *testing.T, req *http.Request, changes Changes, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			it:   "reports header changes and output size without mutating the request",
+			opts: []Option{WithHeaderAllowList("Accept")},
+			setup: func(t *testing.T) *http.Request {
+				req := newRequest(t, http.MethodPost, "http://test.test/test", "test")
+				req.Header.Set("Accept", "*/*")
+				req.Header.Set("Authorization", "Bearer secret")
+				req.Header.Set("Cookie", "session=secret")
+				return req
+			},
+			assert: func(t *testing.T, req *http.Request, changes Changes, err error) {
+				require.NoError(t, err)
+				require.Equal(t, http.Header{"Content-Length": {"4"}}, changes.Set)
+				require.Equal(t, []string{"Authorization", "Cookie"}, changes.Removed)
+				require.Equal(t, 4, changes.ContentLength)
+				require.Empty(t, req.Header.Get("Content-Length"))
+				require.Equal(t, "Bearer secret", req.Header.Get("Authorization"))
+
+				ser, err := New(WithHeaderAllowList("Accept")).Serialize(req)
+				require.NoError(t, err)
+				require.Len(t, ser, changes.Size)
+				require.True(t, bytes.HasSuffix(ser, []byte("\r\n\r\ntest")))
+			},
+		},
+		{
+			it: "reports no header changes when content length is already set",
+			setup: func(t *testing.T) *http.Request {
+				req := newRequest(t, http.MethodGet, "http://test.test/test", "")
+				req.Header.Set("Content-Length", "0")
+				return req
+			},
+			assert: func(t *testing.T, req *http.Request, changes Changes, err error) {
+				require.NoError(t, err)
+				require.Empty(t, changes.Set)
+				require.Empty(t, changes.Removed)
+				require.Equal(t, 0, changes.ContentLength)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			req := tt.setup(t)
+			got, err := New(tt.opts...).Plan(req)
+			tt.assert(t, req, got, err)
+		})
+	}
+}
+
+func TestPlanMatchesSerialize(t *testing.T) {
+	jsonRequest := func(t *testing.T) *http.Request {
+		req := newRequest(t, http.MethodPost, "http://test.test/test", `{"user":{"ssn":"1"}}`)
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+	tests := []struct {
+		it     string
+		opts   []Option
+		setup  func(t *testing.T) *http.Request
+		assert func(t *testing.T, changes Changes)
+	}{
+		{
+			it:    "includes the stable request ID",
+			opts:  []Option{WithStableRequestID("X-Request-Id")},
+			setup: jsonRequest,
+			assert: func(t *testing.T, changes Changes) {
+				require.Len(t, changes.Set.Get("X-Request-Id"), 64)
+			},
+		},
+		{
+			it:    "measures redacted JSON bodies",
+			opts:  []Option{WithJSONBodyRedaction([]string{"$.user.ssn"})},
+			setup: jsonRequest,
+			assert: func(t *testing.T, changes Changes) {
+				require.Equal(t, len(`{"user":{"ssn":"[REDACTED]"}}`), changes.ContentLength)
+			},
+		},
+		{
+			it:   "measures sorted multipart bodies",
+			opts: []Option{WithSortMultipartParts(true)},
+			setup: func(t *testing.T) *http.Request {
+				return newMultipartRequest(t, []byte("photo"))
+			},
+			assert: func(t *testing.T, changes Changes) {
+				require.Contains(t, changes.Set.Get("Content-Type"), sortedPartsBoundary)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			s := New(tt.opts...)
+			req := tt.setup(t)
+			changes, err := s.Plan(req)
+			require.NoError(t, err)
+			tt.assert(t, changes)
+
+			ser, err := s.Serialize(req)
+			require.NoError(t, err)
+			require.Len(t, ser, changes.Size)
+		})
+	}
+}
+
+func TestPlanFailsLikeSerialize(t *testing.T) {
+	for _, opts := range [][]Option{{WithValidateHeaderValues(true)}, {WithMaxURLBytes(4)}} {
+		req := newRequest(t, http.MethodGet, "http://test.test/test", "")
+		req.Header.Set("X-Bad", "a\x01b")
+		_, err := New(opts...).Plan(req)
+		require.Error(t, err)
+		_, serErr := New(opts...).Serialize(req)
+		require.Equal(t, serErr, err)
+	}
+}
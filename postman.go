@@ -0,0 +1,120 @@
+package http_serde
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+type postmanCollection struct {
+	Info postmanInfo   `json:"info"`
+	Item []postmanItem `json:"item"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type postmanItem struct {
+	Name    string         `json:"name"`
+	Request postmanRequest `json:"request"`
+}
+
+type postmanRequest struct {
+	Method string          `json:"method"`
+	Header []postmanPair   `json:"header"`
+	URL    postmanURL      `json:"url"`
+	Body   *postmanRawBody `json:"body,omitempty"`
+}
+
+type postmanPair struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type postmanURL struct {
+	Raw      string        `json:"raw"`
+	Protocol string        `json:"protocol,omitempty"`
+	Host     []string      `json:"host,omitempty"`
+	Port     string        `json:"port,omitempty"`
+	Path     []string      `json:"path,omitempty"`
+	Query    []postmanPair `json:"query,omitempty"`
+}
+
+type postmanRawBody struct {
+	Mode string `json:"mode"`
+	Raw  string `json:"raw"`
+}
+
+// SerializePostman renders the requests as a Postman Collection v2.1 with an
+// item per request.
+func SerializePostman(requests []*http.Request) ([]byte, error) {
+	collection := postmanCollection{
+		Info: postmanInfo{Name: "http-serde", Schema: postmanSchema},
+		Item: make([]postmanItem, 0, len(requests)),
+	}
+	for _, request := range requests {
+		if request == nil {
+			return nil, errors.New("serialize called on nil request")
+		}
+		item, err := newPostmanItem(request)
+		if err != nil {
+			return nil, err
+		}
+		collection.Item = append(collection.Item, item)
+	}
+	return json.Marshal(collection)
+}
+
+func newPostmanItem(request *http.Request) (postmanItem, error) {
+	body, err := (&serde{}).readBody(request)
+	if err != nil {
+		return postmanItem{}, err
+	}
+	u, err := url.Parse(absoluteURL(request))
+	if err != nil {
+		return postmanItem{}, err
+	}
+	req := postmanRequest{
+		Method: request.Method,
+		Header: []postmanPair{},
+		URL: postmanURL{
+			Raw:      u.String(),
+			Protocol: u.Scheme,
+			Host:     strings.Split(u.Hostname(), "."),
+			Port:     u.Port(),
+		},
+	}
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		req.URL.Path = strings.Split(path, "/")
+	}
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		key, _ = url.QueryUnescape(key)
+		value, _ = url.QueryUnescape(value)
+		req.URL.Query = append(req.URL.Query, postmanPair{Key: key, Value: value})
+	}
+	keys := make([]string, 0, len(request.Header))
+	for key := range request.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range request.Header[key] {
+			req.Header = append(req.Header, postmanPair{Key: key, Value: value})
+		}
+	}
+	if len(body) > 0 {
+		req.Body = &postmanRawBody{Mode: "raw", Raw: string(body)}
+	}
+	return postmanItem{Name: request.Method + " " + u.Path, Request: req}, nil
+}
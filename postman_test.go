@@ -0,0 +1,71 @@
+package http_serde
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializePostman(t *testing.T) {
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) []*http.Request
+		assert func(t *testing.T, b []byte, err error)
+	}{
+		{
+			it: "returns an error if a request is nil",
+			setup: func(t *testing.T) []*http.Request {
+				return []*http.Request{nil}
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.Error(t, err)
+				require.Nil(t, b)
+			},
+		},
+		{
+			it: "renders an item per request",
+			setup: func(t *testing.T) []*http.Request {
+				post := newRequest(t, http.MethodPost, "http://test.test:8080/api/users?debug=1&q=a+b", `{"a":"b"}`)
+				post.Header.Set("Content-Type", "application/json")
+				get, err := New().Deserialize(serializeAll(t, newRequest(t, http.MethodGet, "http://test.test/health", "")))
+				require.NoError(t, err)
+				return []*http.Request{post, get}
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				var collection map[string]any
+				require.NoError(t, json.Unmarshal(b, &collection))
+				require.Equal(t, postmanSchema, collection["info"].(map[string]any)["schema"])
+
+				var got postmanCollection
+				require.NoError(t, json.Unmarshal(b, &got))
+				require.Len(t, got.Item, 2)
+				require.Equal(t, postmanRequest{
+					Method: http.MethodPost,
+					Header: []postmanPair{{Key: "Content-Type", Value: "application/json"}},
+					URL: postmanURL{
+						Raw:      "http://test.test:8080/api/users?debug=1&q=a+b",
+						Protocol: "http",
+						Host:     []string{"test", "test"},
+						Port:     "8080",
+						Path:     []string{"api", "users"},
+						Query:    []postmanPair{{Key: "debug", Value: "1"}, {Key: "q", Value: "a b"}},
+					},
+					Body: &postmanRawBody{Mode: "raw", Raw: `{"a":"b"}`},
+				}, got.Item[0].Request)
+				require.Equal(t, "GET /health", got.Item[1].Name)
+				require.Equal(t, http.MethodGet, got.Item[1].Request.Method)
+				require.Equal(t, "http://test.test/health", got.Item[1].Request.URL.Raw)
+				require.Nil(t, got.Item[1].Request.Body)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			got, err := SerializePostman(tt.setup(t))
+			tt.assert(t, got, err)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package http_serde
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// The protobuf format encodes the HttpRequest message described in
+// proto/http_request.proto. Encoding is written against the protobuf wire
+// format directly so the package keeps having no runtime dependencies.
+
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+	protoWireFixed32 = 5
+)
+
+func encodeProtobuf(wire []byte) ([]byte, error) {
+	if len(headLines(wire)) == 0 {
+		return nil, ErrMalformedProtobuf
+	}
+	r, err := parseStructured(wire)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	appendProtoBytes(&buf, 1, []byte(r.Method))
+	appendProtoBytes(&buf, 2, []byte(r.Target))
+	appendProtoBytes(&buf, 3, []byte(r.Proto))
+	for _, h := range r.Headers {
+		var header bytes.Buffer
+		appendProtoBytes(&header, 1, []byte(h.Name))
+		appendProtoBytes(&header, 2, []byte(h.Value))
+		appendProtoField(&buf, 4, header.Bytes())
+	}
+	appendProtoBytes(&buf, 5, r.Body)
+	return buf.Bytes(), nil
+}
+
+func decodeProtobuf(encoded []byte) ([]byte, error) {
+	var r structuredRequest
+	err := readProtoFields(encoded, func(field uint64, value []byte) error {
+		switch field {
+		case 1:
+			r.Method = string(value)
+		case 2:
+			r.Target = string(value)
+		case 3:
+			r.Proto = string(value)
+		case 4:
+			var header structuredHeader
+			err := readProtoFields(value, func(field uint64, value []byte) error {
+				switch field {
+				case 1:
+					header.Name = string(value)
+				case 2:
+					header.Value = string(value)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			r.Headers = append(r.Headers, header)
+		case 5:
+			r.Body = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.wire(), nil
+}
+
+func appendProtoBytes(buf *bytes.Buffer, field uint64, value []byte) {
+	// proto3 omits fields holding their default value
+	if len(value) > 0 {
+		appendProtoField(buf, field, value)
+	}
+}
+
+func appendProtoField(buf *bytes.Buffer, field uint64, value []byte) {
+	var scratch [binary.MaxVarintLen64]byte
+	buf.Write(scratch[:binary.PutUvarint(scratch[:], field<<3|protoWireBytes)])
+	buf.Write(scratch[:binary.PutUvarint(scratch[:], uint64(len(value)))])
+	buf.Write(value)
+}
+
+// readProtoFields calls fn with every length-delimited field of a message,
+// skipping fields of any other wire type.
+func readProtoFields(b []byte, fn func(field uint64, value []byte) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return ErrMalformedProtobuf
+		}
+		b = b[n:]
+		switch tag & 7 {
+		case protoWireVarint:
+			if _, n = binary.Uvarint(b); n <= 0 {
+				return ErrMalformedProtobuf
+			}
+			b = b[n:]
+		case protoWireFixed64, protoWireFixed32:
+			size := 8
+			if tag&7 == protoWireFixed32 {
+				size = 4
+			}
+			if len(b) < size {
+				return ErrMalformedProtobuf
+			}
+			b = b[size:]
+		case protoWireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return ErrMalformedProtobuf
+			}
+			if err := fn(tag>>3, b[n:n+int(l)]); err != nil {
+				return err
+			}
+			b = b[n+int(l):]
+		default:
+			return ErrMalformedProtobuf
+		}
+	}
+	return nil
+}
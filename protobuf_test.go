@@ -0,0 +1,117 @@
+package http_serde
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtobufFormat(t *testing.T) {
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) *http.Request
+		assert func(t *testing.T, encoded []byte, req *http.Request)
+	}{
+		{
+			it: "round-trips requests with an empty body",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodGet, "http://test.test/test?foo=bar", "")
+			},
+			assert: func(t *testing.T, encoded []byte, req *http.Request) {
+				require.Equal(t, []byte("\x0a\x03GET"), encoded[:5])
+				require.Equal(t, http.MethodGet, req.Method)
+				require.Equal(t, "test.test", req.Host)
+				require.Equal(t, "bar", req.URL.Query().Get("foo"))
+				b, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Empty(t, b)
+			},
+		},
+		{
+			it: "round-trips multi-value headers in order",
+			setup: func(t *testing.T) *http.Request {
+				req := newRequest(t, http.MethodGet, "http://test.test/test", "")
+				req.Header.Add("X-Custom", "c")
+				req.Header.Add("X-Custom", "a")
+				req.Header.Add("X-Custom", "b")
+				return req
+			},
+			assert: func(t *testing.T, encoded []byte, req *http.Request) {
+				require.Equal(t, []string{"c", "a", "b"}, req.Header.Values("X-Custom"))
+			},
+		},
+		{
+			it: "round-trips header values holding obs-text",
+			setup: func(t *testing.T) *http.Request {
+				req := newRequest(t, http.MethodGet, "http://test.test/test", "")
+				req.Header.Set("X-Name", "Jos\xe9")
+				return req
+			},
+			assert: func(t *testing.T, encoded []byte, req *http.Request) {
+				require.Equal(t, "Jos\xe9", req.Header.Get("X-Name"))
+			},
+		},
+		{
+			it: "round-trips binary bodies",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodPost, "http://test.test/test", "\x00\xff\r\n\r\n\x01")
+			},
+			assert: func(t *testing.T, encoded []byte, req *http.Request) {
+				b, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, "\x00\xff\r\n\r\n\x01", string(b))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			s := New(WithFormat(FormatProtobuf))
+			encoded, err := s.Serialize(tt.setup(t))
+			require.NoError(t, err)
+			again, err := s.Serialize(tt.setup(t))
+			require.NoError(t, err)
+			require.Equal(t, encoded, again)
+			req, err := s.Deserialize(encoded)
+			require.NoError(t, err)
+			tt.assert(t, encoded, req)
+		})
+	}
+}
+
+func TestProtobufWire(t *testing.T) {
+	wire := []byte("POST /test HTTP/1.1\r\nHost: test.test\r\nContent-Length: 4\r\n\r\ntest")
+	encoded, err := encodeProtobuf(wire)
+	require.NoError(t, err)
+	decoded, err := decodeProtobuf(encoded)
+	require.NoError(t, err)
+	require.Equal(t, wire, decoded)
+
+	t.Run("skips unknown fields", func(t *testing.T) {
+		unknown := append([]byte("\x30\x96\x01\x3d\x00\x00\x00\x00"), encoded...)
+		decoded, err := decodeProtobuf(unknown)
+		require.NoError(t, err)
+		require.Equal(t, wire, decoded)
+	})
+	t.Run("returns an error on truncated messages", func(t *testing.T) {
+		_, err := decodeProtobuf(encoded[:len(encoded)-1])
+		require.ErrorIs(t, err, ErrMalformedProtobuf)
+	})
+}
+
+// testdata/http_request.pb is the protoc encoding of testdata/http_request.txtpb,
+// see that file for the command regenerating it.
+func TestProtobufGolden(t *testing.T) {
+	golden, err := os.ReadFile("testdata/http_request.pb")
+	require.NoError(t, err)
+
+	wire, err := ProtobufCodec{}.Decode(golden)
+	require.NoError(t, err)
+	require.Equal(t, "POST /search?q=caf\xe9 HTTP/1.1\r\nHost: test.test\r\nX-Name: Jos\xe9\r\nContent-Length: 4\r\n\r\ntest", string(wire))
+
+	encoded, err := ProtobufCodec{}.Encode(wire)
+	require.NoError(t, err)
+	require.Equal(t, golden, encoded)
+}
@@ -0,0 +1,149 @@
+package http_serde
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// redactedValue replaces the values matched by WithJSONBodyRedaction.
+const redactedValue = "[REDACTED]"
+
+// jsonPathStep is one step of a parsed JSON path: an object member named key,
+// any member or element when key is "*", or else the array element at index.
+type jsonPathStep struct {
+	key   string
+	index int
+}
+
+// parseJSONPath parses the subset of JSONPath accepted by
+// WithJSONBodyRedaction.
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("%w: %q does not start with $", ErrInvalidJSONPath, path)
+	}
+	var steps []jsonPathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			if end == 1 {
+				return nil, fmt.Errorf("%w: %q has an empty member name", ErrInvalidJSONPath, path)
+			}
+			steps = append(steps, jsonPathStep{key: rest[1:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: %q has an unclosed bracket", ErrInvalidJSONPath, path)
+			}
+			inner := rest[1:end]
+			switch {
+			case inner == "*":
+				steps = append(steps, jsonPathStep{key: "*"})
+			case len(inner) >= 2 && inner[0] == '\'' && inner[len(inner)-1] == '\'':
+				steps = append(steps, jsonPathStep{key: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("%w: %q has an invalid index %q", ErrInvalidJSONPath, path, inner)
+				}
+				steps = append(steps, jsonPathStep{index: index})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("%w: unexpected %q in %q", ErrInvalidJSONPath, rest[0], path)
+		}
+	}
+	return steps, nil
+}
+
+// redactJSON replaces the values of v matched by steps with redactedValue,
+// reporting whether any was.
+func redactJSON(v any, steps []jsonPathStep) (any, bool) {
+	if len(steps) == 0 {
+		return redactedValue, true
+	}
+	step, redacted := steps[0], false
+	switch v := v.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if step.key == "*" || (step.key != "" && step.key == key) {
+				var ok bool
+				v[key], ok = redactJSON(child, steps[1:])
+				redacted = redacted || ok
+			}
+		}
+	case []any:
+		for i, child := range v {
+			if step.key == "*" || (step.key == "" && step.index == i) {
+				var ok bool
+				v[i], ok = redactJSON(child, steps[1:])
+				redacted = redacted || ok
+			}
+		}
+	}
+	return v, redacted
+}
+
+func isJSONMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// redactJSONBody returns a copy of a request with a JSON body whose values
+// at the paths set with WithJSONBodyRedaction are redacted. Other requests,
+// and JSON bodies with nothing to redact, are returned as they are.
+func (s *serde) redactJSONBody(request *http.Request) (*http.Request, error) {
+	if !isJSONMediaType(request.Header.Get("Content-Type")) {
+		return request, nil
+	}
+	paths := make([][]jsonPathStep, 0, len(s.jsonRedactions))
+	for _, path := range s.jsonRedactions {
+		steps, err := parseJSONPath(path)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, steps)
+	}
+	body, err := s.readBody(request)
+	if err != nil || len(body) == 0 {
+		return request, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		// an archive must not keep the values it was asked to redact, even
+		// from bodies it cannot make sense of
+		return nil, fmt.Errorf("redact JSON body: %w", err)
+	}
+	redacted := false
+	for _, steps := range paths {
+		var ok bool
+		doc, ok = redactJSON(doc, steps)
+		redacted = redacted || ok
+	}
+	if !redacted {
+		return request, nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	out := request.Clone(request.Context())
+	out.Body = io.NopCloser(bytes.NewReader(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))))
+	out.ContentLength = int64(buf.Len() - 1)
+	return out, nil
+}
@@ -0,0 +1,105 @@
+package http_serde
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	steps, err := parseJSONPath("$.user['home address'][1].*[*]")
+	require.NoError(t, err)
+	require.Equal(t, []jsonPathStep{{key: "user"}, {key: "home address"}, {index: 1}, {key: "*"}, {key: "*"}}, steps)
+
+	for _, path := range []string{"user.ssn", "$..ssn", "$.cards[", "$.cards[-1]", "$.cards[x]", "$user"} {
+		_, err := parseJSONPath(path)
+		require.ErrorIs(t, err, ErrInvalidJSONPath, path)
+	}
+}
+
+func TestJSONBodyRedaction(t *testing.T) {
+	body := `{"user":{"name":"Ana","ssn":"123-45-6789","age":30},"cards":[{"number":"4111","brand":"visa"},{"number":"5500","brand":"mc"}],"note":"<b>"}`
+	tests := []struct {
+		it          string
+		paths       []string
+		contentType string
+		body        string
+		assert      func(t *testing.T, b []byte, err error)
+	}{
+		{
+			it:          "redacts nested fields leaving their siblings intact",
+			paths:       []string{"$.user.ssn", "$.cards[*].number"},
+			contentType: "application/json; charset=utf-8",
+			body:        body,
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				_, _, got := splitDump(b)
+				require.JSONEq(t, `{"user":{"name":"Ana","ssn":"[REDACTED]","age":30},"cards":[{"number":"[REDACTED]","brand":"visa"},{"number":"[REDACTED]","brand":"mc"}],"note":"<b>"}`, string(got))
+				require.Contains(t, string(b), "Content-Length: 150\r\n")
+				require.Len(t, got, 150)
+			},
+		},
+		{
+			it:          "keeps bodies with nothing to redact byte for byte",
+			paths:       []string{"$.user.password"},
+			contentType: "application/json",
+			body:        body,
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				_, _, got := splitDump(b)
+				require.Equal(t, body, string(got))
+			},
+		},
+		{
+			it:          "leaves bodies of other media types alone",
+			paths:       []string{"$.user.ssn"},
+			contentType: "text/plain",
+			body:        body,
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				_, _, got := splitDump(b)
+				require.Equal(t, body, string(got))
+			},
+		},
+		{
+			it:          "fails on malformed JSON bodies",
+			paths:       []string{"$.user.ssn"},
+			contentType: "application/vnd.api+json",
+			body:        `{"user":`,
+			assert: func(t *testing.T, b []byte, err error) {
+				require.Error(t, err)
+				require.Nil(t, b)
+			},
+		},
+		{
+			it:          "fails on invalid paths",
+			paths:       []string{"user.ssn"},
+			contentType: "application/json",
+			body:        body,
+			assert: func(t *testing.T, b []byte, err error) {
+				require.ErrorIs(t, err, ErrInvalidJSONPath)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			req := newRequest(t, http.MethodPost, "http://test.test/test", tt.body)
+			req.Header.Set("Content-Type", tt.contentType)
+			got, err := New(WithJSONBodyRedaction(tt.paths)).Serialize(req)
+			tt.assert(t, got, err)
+		})
+	}
+}
+
+func TestJSONBodyRedactionLeavesRequestAlone(t *testing.T) {
+	body := `{"user":{"ssn":"123-45-6789"}}`
+	req := newRequest(t, http.MethodPost, "http://test.test/test", body)
+	req.Header.Set("Content-Type", "application/json")
+	_, err := New(WithJSONBodyRedaction([]string{"$.user.ssn"})).Serialize(req)
+	require.NoError(t, err)
+	b, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Equal(t, body, string(b))
+}
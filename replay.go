@@ -0,0 +1,97 @@
+package http_serde
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PrepareReplay deserializes a request and makes it ready to be sent with an
+// http.Client to target, which is either a host[:port] or a base URL.
+func PrepareReplay(serialized []byte, target string) (*http.Request, error) {
+	req, err := New().Deserialize(serialized)
+	if err != nil {
+		return nil, err
+	}
+	base, err := replayTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	req.RequestURI = ""
+	req.URL.Scheme = base.Scheme
+	req.URL.Host = base.Host
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Body.Close(); err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return req, nil
+}
+
+func replayTarget(target string) (*url.URL, error) {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+	return url.Parse(target)
+}
+
+// Replay reads requests written by WriteTimestampedLine from r and sends each
+// of them to the host it was captured for through rt, waiting between them as
+// long as they were apart when captured. It stops at the first error.
+func Replay(ctx context.Context, r io.Reader, rt http.RoundTripper) error {
+	br := bufio.NewReader(r)
+	var start, first time.Time
+	for {
+		req, at, err := ReadTimestampedLine(br)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if start.IsZero() {
+			start, first = time.Now(), at
+		}
+		if err := sleepUntil(ctx, start.Add(at.Sub(first))); err != nil {
+			return err
+		}
+		target, err := url.Parse(absoluteURL(req))
+		if err != nil {
+			return err
+		}
+		req.RequestURI = ""
+		req.URL = target
+		resp, err := rt.RoundTrip(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if err := resp.Body.Close(); err != nil {
+			return err
+		}
+	}
+}
+
+func sleepUntil(ctx context.Context, deadline time.Time) error {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
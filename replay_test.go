@@ -0,0 +1,189 @@
+package http_serde
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareReplay(t *testing.T) {
+	var received *http.Request
+	var receivedBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received, receivedBody = r, string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		it     string
+		target string
+		setup  func(t *testing.T) []byte
+		assert func(t *testing.T, req *http.Request, err error)
+	}{
+		{
+			it:     "returns an error if serialized request is invalid",
+			target: server.URL,
+			setup: func(t *testing.T) []byte {
+				return []byte("INVALID")
+			},
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.Error(t, err)
+				require.Nil(t, req)
+			},
+		},
+		{
+			it:     "prepares requests that can be sent to a base URL",
+			target: server.URL,
+			setup: func(t *testing.T) []byte {
+				req, err := http.NewRequest(http.MethodPost, "http://test.test/test?foo=bar", bytes.NewBufferString("test"))
+				require.NoError(t, err)
+				ser, err := New().Serialize(req)
+				require.NoError(t, err)
+				return ser
+			},
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				require.Empty(t, req.RequestURI)
+				require.NotNil(t, req.GetBody)
+				res, err := http.DefaultClient.Do(req)
+				require.NoError(t, err)
+				defer res.Body.Close()
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, http.MethodPost, received.Method)
+				require.Equal(t, "/test?foo=bar", received.RequestURI)
+				require.Equal(t, "test.test", received.Host)
+				require.Equal(t, "test", receivedBody)
+			},
+		},
+		{
+			it:     "prepares requests that can be sent to a host address",
+			target: strings.TrimPrefix(server.URL, "http://"),
+			setup: func(t *testing.T) []byte {
+				req, err := http.NewRequest(http.MethodGet, "http://test.test/test", nil)
+				require.NoError(t, err)
+				ser, err := New().Serialize(req)
+				require.NoError(t, err)
+				return ser
+			},
+			assert: func(t *testing.T, req *http.Request, err error) {
+				require.NoError(t, err)
+				res, err := http.DefaultClient.Do(req)
+				require.NoError(t, err)
+				defer res.Body.Close()
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, "/test", received.RequestURI)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			got, err := PrepareReplay(tt.setup(t), tt.target)
+			tt.assert(t, got, err)
+		})
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestReplay(t *testing.T) {
+	capture := func(t *testing.T, offsets ...time.Duration) *bytes.Buffer {
+		var buf bytes.Buffer
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i, offset := range offsets {
+			req := newRequest(t, http.MethodPost, "http://test.test/"+string(rune('a'+i)), "test")
+			require.NoError(t, WriteTimestampedLine(&buf, req, start.Add(offset)))
+		}
+		return &buf
+	}
+	type dispatch struct {
+		at   time.Time
+		url  string
+		body string
+	}
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) (context.Context, io.Reader)
+		err    error
+		assert func(t *testing.T, dispatched []dispatch, err error)
+	}{
+		{
+			it: "dispatches requests with their original spacing",
+			setup: func(t *testing.T) (context.Context, io.Reader) {
+				return context.Background(), capture(t, 0, 50*time.Millisecond)
+			},
+			assert: func(t *testing.T, dispatched []dispatch, err error) {
+				require.NoError(t, err)
+				require.Len(t, dispatched, 2)
+				require.Equal(t, "http://test.test/a", dispatched[0].url)
+				require.Equal(t, "http://test.test/b", dispatched[1].url)
+				require.Equal(t, "test", dispatched[1].body)
+				gap := dispatched[1].at.Sub(dispatched[0].at)
+				require.GreaterOrEqual(t, gap, 45*time.Millisecond)
+				require.Less(t, gap, 500*time.Millisecond)
+			},
+		},
+		{
+			it: "stops when the round tripper fails",
+			setup: func(t *testing.T) (context.Context, io.Reader) {
+				return context.Background(), capture(t, 0, 0)
+			},
+			err: errors.New("test"),
+			assert: func(t *testing.T, dispatched []dispatch, err error) {
+				require.EqualError(t, err, "test")
+				require.Len(t, dispatched, 1)
+			},
+		},
+		{
+			it: "stops when the context is canceled while waiting",
+			setup: func(t *testing.T) (context.Context, io.Reader) {
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+				t.Cleanup(cancel)
+				return ctx, capture(t, 0, time.Hour)
+			},
+			assert: func(t *testing.T, dispatched []dispatch, err error) {
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+				require.Len(t, dispatched, 1)
+			},
+		},
+		{
+			it: "returns an error for malformed lines",
+			setup: func(t *testing.T) (context.Context, io.Reader) {
+				return context.Background(), strings.NewReader("INVALID\n")
+			},
+			assert: func(t *testing.T, dispatched []dispatch, err error) {
+				require.Error(t, err)
+				require.Empty(t, dispatched)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			var dispatched []dispatch
+			rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				b, _ := io.ReadAll(req.Body)
+				dispatched = append(dispatched, dispatch{at: time.Now(), url: req.URL.String(), body: string(b)})
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+			})
+			ctx, r := tt.setup(t)
+			err := Replay(ctx, r, rt)
+			tt.assert(t, dispatched, err)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package http_serde
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httputil"
+)
+
+// withStableRequestID returns a copy of the request with the header set with
+// WithStableRequestID holding its fingerprint.
+func (s *serde) withStableRequestID(request *http.Request) (*http.Request, error) {
+	body, err := s.readBody(request)
+	if err != nil {
+		return nil, err
+	}
+	out := request.Clone(request.Context())
+	out.Header.Del(s.stableIDHeader)
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	dump, err := httputil.DumpRequest(out, true)
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := Canonicalize(dump)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	out.Header.Set(s.stableIDHeader, hex.EncodeToString(sum[:]))
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	return out, nil
+}
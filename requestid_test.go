@@ -0,0 +1,42 @@
+package http_serde
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStableRequestID(t *testing.T) {
+	s := New(WithStableRequestID("x-capture-id"))
+	id := func(t *testing.T, req *http.Request) string {
+		ser, err := s.Serialize(req)
+		require.NoError(t, err)
+		got, err := New().Deserialize(ser)
+		require.NoError(t, err)
+		require.Len(t, got.Header.Get("X-Capture-Id"), 64)
+		return got.Header.Get("X-Capture-Id")
+	}
+
+	a := newRequest(t, http.MethodPost, "http://test.test/test?a=1&b=2", "test")
+	a.Header.Set("Accept", "*/*")
+	a.Header.Set("X-Trace", "1")
+	b := newRequest(t, http.MethodPost, "http://test.test/test?b=2&a=1", "test")
+	b.Header.Set("X-Trace", "1")
+	b.Header.Set("Accept", "*/*")
+	require.Equal(t, id(t, a), id(t, b))
+	require.Empty(t, a.Header.Get("X-Capture-Id"))
+
+	other := newRequest(t, http.MethodPost, "http://test.test/test?a=1&b=2", "tset")
+	other.Header.Set("Accept", "*/*")
+	other.Header.Set("X-Trace", "1")
+	require.NotEqual(t, id(t, a), id(t, other))
+
+	ser, err := s.Serialize(newRequest(t, http.MethodGet, "http://test.test/test", ""))
+	require.NoError(t, err)
+	req, err := s.Deserialize(ser)
+	require.NoError(t, err)
+	again, err := s.Serialize(req)
+	require.NoError(t, err)
+	require.Equal(t, string(ser), string(again))
+}
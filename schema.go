@@ -0,0 +1,64 @@
+package http_serde
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// JSONSchema returns the JSON Schema document describing the payloads written
+// by JSONCodec, derived from the Go definition of the payload so it cannot
+// drift from it. Teams consuming the payloads from other languages can use it
+// to validate them or generate bindings.
+func JSONSchema() []byte {
+	schema := jsonSchemaOf(reflect.TypeOf(structuredRequest{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "HTTP request"
+	schema["description"] = "An HTTP/1.1 request as encoded by JSONCodec."
+	out, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		// only maps, slices and strings are marshaled
+		panic(err)
+	}
+	return out
+}
+
+func jsonSchemaOf(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := map[string]any{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = jsonSchemaOf(field.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": jsonSchemaOf(t.Elem())}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
@@ -0,0 +1,48 @@
+package http_serde
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	var schema struct {
+		Type       string                     `json:"type"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	require.NoError(t, json.Unmarshal(JSONSchema(), &schema))
+	require.Equal(t, "object", schema.Type)
+	require.ElementsMatch(t, []string{"version", "method", "target", "proto", "headers", "body"}, propertyNames(schema.Properties))
+	require.ElementsMatch(t, []string{"method", "target", "proto"}, schema.Required)
+	require.JSONEq(t, `{"type":"string","contentEncoding":"base64"}`, string(schema.Properties["body"]))
+	require.JSONEq(t, `{
+		"type": "array",
+		"items": {
+			"type": "object",
+			"properties": {"name": {"type": "string"}, "value": {"type": "string"}},
+			"required": ["name", "value"],
+			"additionalProperties": false
+		}
+	}`, string(schema.Properties["headers"]))
+
+	req := newRequest(t, http.MethodPost, "http://test.test/test", "test")
+	ser, err := New(WithCodec(JSONCodec{})).Serialize(req)
+	require.NoError(t, err)
+	var payload map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(ser, &payload))
+	for key := range payload {
+		require.Contains(t, schema.Properties, key)
+	}
+}
+
+func propertyNames(m map[string]json.RawMessage) []string {
+	out := make([]string, 0, len(m))
+	for key := range m {
+		out = append(out, key)
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package http_serde
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// SegmentReader presents ordered byte segments, such as the TCP payloads of
+// a connection reconstructed from a packet capture, as one continuous
+// io.Reader. Reads block until a segment is written, and return io.EOF once
+// the reader is closed and every segment has been read, so DeserializeFrom
+// buffers until the whole request has arrived. DeserializeReader, reading
+// through a bufio.Reader, returns as soon as the request is complete instead,
+// without waiting for Close. Segments must be contiguous, as gaps cannot be
+// told apart from the bytes around them.
+//
+// A SegmentReader is safe for a writer and a reader in different goroutines.
+type SegmentReader struct {
+	mu     sync.Mutex
+	more   *sync.Cond
+	buf    bytes.Buffer
+	closed bool
+}
+
+// NewSegmentReader returns an empty SegmentReader.
+func NewSegmentReader() *SegmentReader {
+	r := &SegmentReader{}
+	r.more = sync.NewCond(&r.mu)
+	return r
+}
+
+// Write appends a copy of segment to the stream. It never blocks, and fails
+// with io.ErrClosedPipe once the reader is closed.
+func (r *SegmentReader) Write(segment []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, io.ErrClosedPipe
+	}
+	r.buf.Write(segment)
+	r.more.Broadcast()
+	return len(segment), nil
+}
+
+// Close marks the end of the stream: reads return io.EOF once the segments
+// written so far have been read.
+func (r *SegmentReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.more.Broadcast()
+	return nil
+}
+
+func (r *SegmentReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for r.buf.Len() == 0 && !r.closed {
+		r.more.Wait()
+	}
+	if r.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return r.buf.Read(p)
+}
@@ -0,0 +1,65 @@
+package http_serde
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegmentReader(t *testing.T) {
+	serialized := []byte("POST /test HTTP/1.1\r\nHost: test.test\r\nContent-Length: 11\r\n\r\nhello world")
+	segments := [][]byte{serialized[:9], serialized[9:50], serialized[50:]}
+	tests := []struct {
+		it          string
+		deserialize func(s SerDe, r *SegmentReader) (*http.Request, error)
+		close       bool
+	}{
+		{
+			it: "feeds DeserializeFrom once closed",
+			deserialize: func(s SerDe, r *SegmentReader) (*http.Request, error) {
+				return s.DeserializeFrom(r)
+			},
+			close: true,
+		},
+		{
+			it: "feeds DeserializeReader as soon as the request is complete",
+			deserialize: func(s SerDe, r *SegmentReader) (*http.Request, error) {
+				return s.DeserializeReader(bufio.NewReader(r))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			r := NewSegmentReader()
+			go func() {
+				for _, segment := range segments {
+					_, _ = r.Write(segment)
+				}
+				if tt.close {
+					_ = r.Close()
+				}
+			}()
+			req, err := tt.deserialize(New(), r)
+			require.NoError(t, err)
+			require.Equal(t, "/test", req.URL.Path)
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			require.Equal(t, "hello world", string(body))
+		})
+	}
+}
+
+func TestSegmentReaderClosed(t *testing.T) {
+	r := NewSegmentReader()
+	_, err := r.Write([]byte("GET / HTTP/1.1\r\n"))
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	_, err = r.Write([]byte("\r\n"))
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "GET / HTTP/1.1\r\n", string(b))
+}
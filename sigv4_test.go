@@ -0,0 +1,105 @@
+package http_serde
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// signV4 computes an AWS Signature Version 4 Authorization header for req,
+// signing host and every X-Amz-* header.
+func signV4(t *testing.T, req *http.Request, body []byte) string {
+	t.Helper()
+	const region, service = "us-east-1", "s3"
+	amzDate := req.Header.Get("X-Amz-Date")
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for key, values := range req.Header {
+		if strings.HasPrefix(strings.ToLower(key), "x-amz-") {
+			headers[strings.ToLower(key)] = strings.Join(values, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, strings.TrimSpace(headers[name]))
+	}
+	signedHeaders := strings.Join(names, ";")
+	payload := sha256.Sum256(body)
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payload[:]),
+	}, "\n")
+	scope := amzDate[:8] + "/" + region + "/" + service + "/aws4_request"
+	hashed := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+	key := []byte("AWS4" + "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY")
+	for _, part := range []string{amzDate[:8], region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return fmt.Sprintf("AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/%s, SignedHeaders=%s, Signature=%s",
+		scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign)))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func TestSigV4RoundTrip(t *testing.T) {
+	formats := []struct {
+		name   string
+		format Format
+	}{
+		{name: "wire", format: FormatWire},
+		{name: "protobuf", format: FormatProtobuf},
+	}
+	for _, f := range formats {
+		t.Run("keeps SigV4 signed headers byte-exact through "+f.name, func(t *testing.T) {
+			body := []byte("{\"a\":\"b\"}")
+			payload := sha256.Sum256(body)
+			req := newRequest(t, http.MethodPut, "https://bucket.s3.amazonaws.com/key%20name?versionId=1&acl", string(body))
+			req.Header.Set("X-Amz-Date", "20240101T000000Z")
+			req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payload[:]))
+			req.Header.Set("X-Amz-Meta-Note", "a  b")
+			authorization := signV4(t, req, body)
+			req.Header.Set("Authorization", authorization)
+
+			s := New(WithFormat(f.format))
+			ser, err := s.Serialize(req)
+			require.NoError(t, err)
+			got, err := s.Deserialize(ser)
+			require.NoError(t, err)
+			require.Equal(t, authorization, got.Header.Get("Authorization"))
+			require.Equal(t, "bucket.s3.amazonaws.com", got.Host)
+			for _, key := range []string{"X-Amz-Date", "X-Amz-Content-Sha256", "X-Amz-Meta-Note"} {
+				require.Equal(t, req.Header.Values(key), got.Header.Values(key), key)
+			}
+			gotBody, err := io.ReadAll(got.Body)
+			require.NoError(t, err)
+			require.Equal(t, body, gotBody)
+			got.Header.Del("Authorization")
+			require.Equal(t, authorization, signV4(t, got, gotBody))
+		})
+	}
+}
@@ -0,0 +1,37 @@
+//go:build go1.21
+
+package http_serde
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// LogValue summarizes the request for log/slog as a group with its method,
+// URL, host, header count and body size. The URL leaves out credentials and
+// the query string, and neither header values nor the body are included.
+func LogValue(request *http.Request) slog.Value {
+	if request == nil {
+		return slog.GroupValue()
+	}
+	url := ""
+	if request.URL != nil {
+		u := *request.URL
+		u.User = nil
+		u.RawQuery = ""
+		u.ForceQuery = false
+		u.Fragment, u.RawFragment = "", ""
+		url = u.String()
+	}
+	host := request.Host
+	if host == "" && request.URL != nil {
+		host = request.URL.Host
+	}
+	return slog.GroupValue(
+		slog.String("method", request.Method),
+		slog.String("url", url),
+		slog.String("host", host),
+		slog.Int("header_count", len(request.Header)),
+		slog.Int64("body_size", request.ContentLength),
+	)
+}
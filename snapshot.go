@@ -0,0 +1,65 @@
+package http_serde
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// RequestSnapshot is a plain data copy of an http.Request, free of readers
+// and contexts, that can be compared, stored or encoded as is.
+type RequestSnapshot struct {
+	Method  string              `json:"method"`
+	URL     string              `json:"url"`
+	Host    string              `json:"host,omitempty"`
+	Proto   string              `json:"proto,omitempty"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    []byte              `json:"body,omitempty"`
+}
+
+// Snapshot copies the request into a RequestSnapshot. The request body is
+// read and replaced with an equivalent one.
+func Snapshot(request *http.Request) (*RequestSnapshot, error) {
+	if request == nil {
+		return nil, errors.New("snapshot called on nil request")
+	}
+	body, err := (&serde{}).readBody(request)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &RequestSnapshot{
+		Method:  request.Method,
+		Host:    request.Host,
+		Proto:   request.Proto,
+		Headers: request.Header.Clone(),
+		Body:    body,
+	}
+	if request.URL != nil {
+		snapshot.URL = request.URL.String()
+	}
+	return snapshot, nil
+}
+
+// ToRequest rebuilds an http.Request from the snapshot.
+func (s *RequestSnapshot) ToRequest() (*http.Request, error) {
+	req, err := http.NewRequest(s.Method, s.URL, bytes.NewReader(s.Body))
+	if err != nil {
+		return nil, err
+	}
+	if s.Host != "" {
+		req.Host = s.Host
+	}
+	if s.Proto != "" {
+		major, minor, ok := http.ParseHTTPVersion(s.Proto)
+		if !ok {
+			return nil, fmt.Errorf("malformed HTTP version %q", s.Proto)
+		}
+		req.Proto, req.ProtoMajor, req.ProtoMinor = s.Proto, major, minor
+	}
+	req.Header = http.Header(s.Headers).Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	return req, nil
+}
@@ -0,0 +1,91 @@
+package http_serde
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) *http.Request
+		assert func(t *testing.T, req *http.Request, snapshot *RequestSnapshot, err error)
+	}{
+		{
+			it: "returns an error if http request is nil",
+			setup: func(t *testing.T) *http.Request {
+				return nil
+			},
+			assert: func(t *testing.T, req *http.Request, snapshot *RequestSnapshot, err error) {
+				require.Error(t, err)
+				require.Nil(t, snapshot)
+			},
+		},
+		{
+			it: "round-trips requests through a snapshot",
+			setup: func(t *testing.T) *http.Request {
+				req := newRequest(t, http.MethodPost, "http://test.test/test?foo=bar", "test")
+				req.Header.Add("X-Custom", "a")
+				req.Header.Add("X-Custom", "b")
+				return req
+			},
+			assert: func(t *testing.T, req *http.Request, snapshot *RequestSnapshot, err error) {
+				require.NoError(t, err)
+				require.Equal(t, &RequestSnapshot{
+					Method:  http.MethodPost,
+					URL:     "http://test.test/test?foo=bar",
+					Host:    "test.test",
+					Proto:   "HTTP/1.1",
+					Headers: map[string][]string{"X-Custom": {"a", "b"}},
+					Body:    []byte("test"),
+				}, snapshot)
+
+				b, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, "test", string(b))
+
+				rebuilt, err := snapshot.ToRequest()
+				require.NoError(t, err)
+				again, err := Snapshot(rebuilt)
+				require.NoError(t, err)
+				require.Equal(t, snapshot, again)
+			},
+		},
+		{
+			it: "round-trips deserialized requests through a snapshot",
+			setup: func(t *testing.T) *http.Request {
+				req, err := New().Deserialize(serializeAll(t, newRequest(t, http.MethodGet, "http://test.test/test", "")))
+				require.NoError(t, err)
+				return req
+			},
+			assert: func(t *testing.T, req *http.Request, snapshot *RequestSnapshot, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "/test", snapshot.URL)
+				require.Equal(t, "test.test", snapshot.Host)
+
+				rebuilt, err := snapshot.ToRequest()
+				require.NoError(t, err)
+				require.Equal(t, "test.test", rebuilt.Host)
+				require.Equal(t, "/test", rebuilt.URL.Path)
+				ser, err := New().Serialize(rebuilt)
+				require.NoError(t, err)
+				require.Equal(t, "GET /test HTTP/1.1\r\nHost: test.test\r\nContent-Length: 0\r\n\r\n", string(ser))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			req := tt.setup(t)
+			got, err := Snapshot(req)
+			tt.assert(t, req, got, err)
+		})
+	}
+
+	t.Run("returns an error if the snapshot version is malformed", func(t *testing.T) {
+		_, err := (&RequestSnapshot{Method: http.MethodGet, URL: "/", Proto: "HTTP/x"}).ToRequest()
+		require.Error(t, err)
+	})
+}
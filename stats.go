@@ -0,0 +1,58 @@
+package http_serde
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// Stats describes the size of a deserialized request. HeaderBytes counts the
+// whole head, request line and blank line included, and BodyBytes the body
+// once decoded from any transfer encoding.
+type Stats struct {
+	HeaderCount int
+	HeaderBytes int
+	BodyBytes   int64
+}
+
+// DeserializeWithStats deserializes the request and reports its size. The
+// body is buffered to be measured, and stays seekable under WithSeekableBody.
+func (s *serde) DeserializeWithStats(serialized []byte) (*http.Request, Stats, error) {
+	if s.maxInputBytes > 0 && int64(len(serialized)) > s.maxInputBytes {
+		return nil, Stats{}, ErrInputTooLarge
+	}
+	wire, err := s.decode(serialized)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	req, err := s.deserializeWire(wire)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	if s.seekableBody {
+		req.Body = seekableBody{bytes.NewReader(body)}
+	} else {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return req, Stats{
+		HeaderCount: len(headLines(wire)) - 1,
+		HeaderBytes: headEnd(wire),
+		BodyBytes:   int64(len(body)),
+	}, nil
+}
+
+// DeserializeWithBodyInfo deserializes the request and reports whether it
+// declares a body, through Content-Length or Transfer-Encoding, telling an
+// empty body apart from an absent one.
+func (s *serde) DeserializeWithBodyInfo(serialized []byte) (*http.Request, bool, error) {
+	req, err := s.Deserialize(serialized)
+	if err != nil {
+		return nil, false, err
+	}
+	_, declared := req.Header["Content-Length"]
+	return req, declared || len(req.TransferEncoding) > 0, nil
+}
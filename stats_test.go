@@ -0,0 +1,157 @@
+package http_serde
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeserializeWithStats(t *testing.T) {
+	tests := []struct {
+		it     string
+		opts   []Option
+		setup  func(t *testing.T) []byte
+		assert func(t *testing.T, req *http.Request, stats Stats, err error)
+	}{
+		{
+			it: "returns an error if serialized request is invalid",
+			setup: func(t *testing.T) []byte {
+				return []byte("INVALID")
+			},
+			assert: func(t *testing.T, req *http.Request, stats Stats, err error) {
+				require.Error(t, err)
+				require.Nil(t, req)
+				require.Equal(t, Stats{}, stats)
+			},
+		},
+		{
+			it: "counts headers and body bytes",
+			setup: func(t *testing.T) []byte {
+				return []byte(strings.Join([]string{
+					"POST /test HTTP/1.1",
+					"Host: test.test",
+					"X-Custom: a",
+					"X-Custom: b",
+					"Content-Length: 4",
+					"",
+					"test",
+				}, "\r\n"))
+			},
+			assert: func(t *testing.T, req *http.Request, stats Stats, err error) {
+				require.NoError(t, err)
+				require.Equal(t, Stats{
+					HeaderCount: 4,
+					HeaderBytes: len("POST /test HTTP/1.1\r\nHost: test.test\r\nX-Custom: a\r\nX-Custom: b\r\nContent-Length: 4\r\n\r\n"),
+					BodyBytes:   4,
+				}, stats)
+				b, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, "test", string(b))
+			},
+		},
+		{
+			it: "counts decoded chunked body bytes",
+			setup: func(t *testing.T) []byte {
+				return []byte("POST /test HTTP/1.1\r\nHost: test.test\r\nTransfer-Encoding: chunked\r\n\r\n4\r\ntest\r\n0\r\n\r\n")
+			},
+			assert: func(t *testing.T, req *http.Request, stats Stats, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 2, stats.HeaderCount)
+				require.Equal(t, int64(4), stats.BodyBytes)
+			},
+		},
+		{
+			it:   "keeps the body seekable",
+			opts: []Option{WithSeekableBody(true)},
+			setup: func(t *testing.T) []byte {
+				return []byte("POST /test HTTP/1.1\r\nHost: test.test\r\nContent-Length: 4\r\n\r\ntest")
+			},
+			assert: func(t *testing.T, req *http.Request, stats Stats, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(4), stats.BodyBytes)
+				seeker, ok := req.Body.(io.ReadSeeker)
+				require.True(t, ok)
+				b, err := io.ReadAll(seeker)
+				require.NoError(t, err)
+				require.Equal(t, "test", string(b))
+				_, err = seeker.Seek(0, io.SeekStart)
+				require.NoError(t, err)
+				b, err = io.ReadAll(seeker)
+				require.NoError(t, err)
+				require.Equal(t, "test", string(b))
+			},
+		},
+		{
+			it:   "enforces the maximum input size",
+			opts: []Option{WithMaxInputBytes(16)},
+			setup: func(t *testing.T) []byte {
+				return []byte("POST /test HTTP/1.1\r\nHost: test.test\r\nContent-Length: 4\r\n\r\ntest")
+			},
+			assert: func(t *testing.T, req *http.Request, stats Stats, err error) {
+				require.ErrorIs(t, err, ErrInputTooLarge)
+				require.Nil(t, req)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			got, stats, err := New(tt.opts...).DeserializeWithStats(tt.setup(t))
+			tt.assert(t, got, stats, err)
+		})
+	}
+}
+
+func TestDeserializeWithBodyInfo(t *testing.T) {
+	tests := []struct {
+		it         string
+		serialized string
+		hasBody    bool
+		body       string
+		err        bool
+	}{
+		{
+			it:         "returns an error if serialized request is invalid",
+			serialized: "INVALID",
+			err:        true,
+		},
+		{
+			it:         "reports bodiless requests",
+			serialized: "GET /test HTTP/1.1\r\nHost: test.test\r\n\r\n",
+			hasBody:    false,
+		},
+		{
+			it:         "reports zero-length declared bodies",
+			serialized: "POST /test HTTP/1.1\r\nHost: test.test\r\nContent-Length: 0\r\n\r\n",
+			hasBody:    true,
+		},
+		{
+			it:         "reports bodies",
+			serialized: "POST /test HTTP/1.1\r\nHost: test.test\r\nContent-Length: 4\r\n\r\ntest",
+			hasBody:    true,
+			body:       "test",
+		},
+		{
+			it:         "reports chunked bodies",
+			serialized: "POST /test HTTP/1.1\r\nHost: test.test\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n",
+			hasBody:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			req, hasBody, err := New().DeserializeWithBodyInfo([]byte(tt.serialized))
+			if tt.err {
+				require.Error(t, err)
+				require.Nil(t, req)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.hasBody, hasBody)
+			b, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			require.Equal(t, tt.body, string(b))
+		})
+	}
+}
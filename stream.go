@@ -0,0 +1,185 @@
+package http_serde
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DecodeStream reads consecutive serialized requests from r and emits them
+// one at a time, so a slow consumer naturally throttles reading. Both channels
+// are closed once r is exhausted, an error occurs or ctx is canceled.
+func DecodeStream(ctx context.Context, r io.Reader) (<-chan *http.Request, <-chan error) {
+	requests := make(chan *http.Request)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(requests)
+		br := bufio.NewReader(r)
+		s := &serde{}
+		for {
+			if err := ctx.Err(); err != nil {
+				errs <- err
+				return
+			}
+			req, err := s.DeserializeReader(br)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case requests <- req:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return requests, errs
+}
+
+// DeserializeReader reads a single serialized request in the wire format from
+// br, leaving any bytes after it buffered in br for the next call. It returns
+// io.EOF when br holds no more requests.
+func (s *serde) DeserializeReader(br *bufio.Reader) (*http.Request, error) {
+	serialized, err := readRawRequest(br, s.maxInputBytes)
+	if err != nil {
+		return nil, err
+	}
+	return s.deserializeWire(serialized)
+}
+
+// rawReader accumulates the bytes of a request read from br, failing with
+// ErrInputTooLarge as soon as they would exceed limit, when positive.
+type rawReader struct {
+	br    *bufio.Reader
+	raw   []byte
+	limit int64
+}
+
+// readLine appends the next line of br to raw and returns it.
+func (r *rawReader) readLine() ([]byte, error) {
+	start := len(r.raw)
+	for {
+		fragment, err := r.br.ReadSlice('\n')
+		r.raw = append(r.raw, fragment...)
+		if r.limit > 0 && int64(len(r.raw)) > r.limit {
+			return nil, ErrInputTooLarge
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return r.raw[start:], err
+		}
+	}
+}
+
+// readN appends the next n bytes of br to raw, growing it as they arrive
+// rather than trusting n up front.
+func (r *rawReader) readN(n int64) error {
+	if r.limit > 0 && int64(len(r.raw))+n > r.limit {
+		return ErrInputTooLarge
+	}
+	buf := bytes.NewBuffer(r.raw)
+	if _, err := io.CopyN(buf, r.br, n); err != nil {
+		return noEOF(err)
+	}
+	r.raw = buf.Bytes()
+	return nil
+}
+
+// readRawRequest reads the bytes of a single request from br, finding where
+// its body ends from its Content-Length or chunked Transfer-Encoding header,
+// and giving up with ErrInputTooLarge once more than limit bytes, when
+// positive, have been read. Empty lines before the request line, which some
+// clients send after a body on persistent connections (RFC 7230, section
+// 3.5), are skipped.
+func readRawRequest(br *bufio.Reader, limit int64) ([]byte, error) {
+	r := &rawReader{br: br, limit: limit}
+	for {
+		line, err := r.readLine()
+		if errors.Is(err, ErrInputTooLarge) {
+			return nil, err
+		}
+		if len(r.raw) == len(line) && err == nil && len(bytes.TrimRight(line, "\r\n")) == 0 {
+			r.raw = r.raw[:0]
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(bytes.TrimSpace(r.raw)) > 0 {
+				return r.raw, nil
+			}
+			return nil, err
+		}
+		if len(bytes.TrimRight(line, "\r\n")) == 0 {
+			break
+		}
+	}
+	for _, value := range rawHeaderValues(r.raw, "Transfer-Encoding") {
+		if strings.Contains(strings.ToLower(value), "chunked") {
+			return readRawChunks(r)
+		}
+	}
+	lengths := rawHeaderValues(r.raw, "Content-Length")
+	if len(lengths) == 0 {
+		return r.raw, nil
+	}
+	n, err := strconv.ParseInt(lengths[0], 10, 64)
+	if err != nil || n <= 0 {
+		// leave an invalid length for deserializeWire to report
+		return r.raw, nil
+	}
+	if err := r.readN(n); err != nil {
+		return nil, err
+	}
+	return r.raw, nil
+}
+
+// readRawChunks appends the chunked body and trailers read from br to raw.
+func readRawChunks(r *rawReader) ([]byte, error) {
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, noEOF(err)
+		}
+		size, _, _ := strings.Cut(strings.TrimSpace(string(line)), ";")
+		n, err := strconv.ParseInt(strings.TrimSpace(size), 16, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("malformed chunk size %q", size)
+		}
+		if n == 0 {
+			break
+		}
+		if err := r.readN(n); err != nil {
+			return nil, err
+		}
+		if _, err := r.readLine(); err != nil {
+			return nil, noEOF(err)
+		}
+	}
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, noEOF(err)
+		}
+		if len(bytes.TrimRight(line, "\r\n")) == 0 {
+			return r.raw, nil
+		}
+	}
+}
+
+// noEOF turns io.EOF into io.ErrUnexpectedEOF, for reads that started a
+// request and could not finish it.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,264 @@
+package http_serde
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func serializeAll(t *testing.T, reqs ...*http.Request) []byte {
+	var buf bytes.Buffer
+	for _, req := range reqs {
+		ser, err := New().Serialize(req)
+		require.NoError(t, err)
+		buf.Write(ser)
+	}
+	return buf.Bytes()
+}
+
+func newRequest(t *testing.T, method, url, body string) *http.Request {
+	var reader io.Reader
+	if body != "" {
+		reader = bytes.NewBufferString(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	require.NoError(t, err)
+	return req
+}
+
+func TestDecodeStream(t *testing.T) {
+	var cancelStream context.CancelFunc
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) (context.Context, io.Reader)
+		assert func(t *testing.T, requests <-chan *http.Request, errs <-chan error)
+	}{
+		{
+			it: "emits every request until the reader is exhausted",
+			setup: func(t *testing.T) (context.Context, io.Reader) {
+				return context.Background(), bytes.NewReader(serializeAll(t,
+					newRequest(t, http.MethodPost, "http://test.test/a", "a"),
+					newRequest(t, http.MethodGet, "http://test.test/b", ""),
+					newRequest(t, http.MethodPut, "http://test.test/c", "ccc"),
+				))
+			},
+			assert: func(t *testing.T, requests <-chan *http.Request, errs <-chan error) {
+				var paths, bodies []string
+				for req := range requests {
+					b, err := io.ReadAll(req.Body)
+					require.NoError(t, err)
+					paths = append(paths, req.URL.Path)
+					bodies = append(bodies, string(b))
+				}
+				require.NoError(t, <-errs)
+				require.Equal(t, []string{"/a", "/b", "/c"}, paths)
+				require.Equal(t, []string{"a", "", "ccc"}, bodies)
+			},
+		},
+		{
+			it: "surfaces decoding errors",
+			setup: func(t *testing.T) (context.Context, io.Reader) {
+				ser := serializeAll(t, newRequest(t, http.MethodGet, "http://test.test/a", ""))
+				return context.Background(), bytes.NewReader(append(ser, []byte("INVALID\r\n\r\n")...))
+			},
+			assert: func(t *testing.T, requests <-chan *http.Request, errs <-chan error) {
+				require.Equal(t, "/a", (<-requests).URL.Path)
+				_, ok := <-requests
+				require.False(t, ok)
+				require.Error(t, <-errs)
+			},
+		},
+		{
+			it: "stops when the context is canceled",
+			setup: func(t *testing.T) (context.Context, io.Reader) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancelStream = cancel
+				return ctx, bytes.NewReader(serializeAll(t,
+					newRequest(t, http.MethodGet, "http://test.test/a", ""),
+					newRequest(t, http.MethodGet, "http://test.test/b", ""),
+					newRequest(t, http.MethodGet, "http://test.test/c", ""),
+				))
+			},
+			assert: func(t *testing.T, requests <-chan *http.Request, errs <-chan error) {
+				require.Equal(t, "/a", (<-requests).URL.Path)
+				cancelStream()
+				for range requests {
+				}
+				require.ErrorIs(t, <-errs, context.Canceled)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			ctx, r := tt.setup(t)
+			requests, errs := DecodeStream(ctx, r)
+			tt.assert(t, requests, errs)
+		})
+	}
+}
+
+func TestDeserializeReader(t *testing.T) {
+	tests := []struct {
+		it     string
+		opts   []Option
+		input  func(t *testing.T) string
+		assert func(t *testing.T, br *bufio.Reader, s SerDe)
+	}{
+		{
+			it: "reads pipelined requests one at a time",
+			input: func(t *testing.T) string {
+				return string(serializeAll(t,
+					newRequest(t, http.MethodPost, "http://test.test/a", "a"),
+					newRequest(t, http.MethodGet, "http://test.test/b", ""),
+				)) + "POST /c HTTP/1.1\r\nHost: test.test\r\nTransfer-Encoding: chunked\r\n\r\n3;ext=1\r\nccc\r\n0\r\nX-Trailer: t\r\n\r\n"
+			},
+			assert: func(t *testing.T, br *bufio.Reader, s SerDe) {
+				for _, expected := range []struct{ path, body string }{{"/a", "a"}, {"/b", ""}, {"/c", "ccc"}} {
+					req, err := s.DeserializeReader(br)
+					require.NoError(t, err)
+					require.Equal(t, expected.path, req.URL.Path)
+					b, err := io.ReadAll(req.Body)
+					require.NoError(t, err)
+					require.Equal(t, expected.body, string(b))
+				}
+				_, err := s.DeserializeReader(br)
+				require.ErrorIs(t, err, io.EOF)
+			},
+		},
+		{
+			it: "skips empty lines between pipelined requests",
+			input: func(t *testing.T) string {
+				return "POST /a HTTP/1.1\r\nHost: test.test\r\nContent-Length: 1\r\n\r\na\r\n" +
+					"\r\nGET /b HTTP/1.1\r\nHost: test.test\r\n\r\n" +
+					"POST /c HTTP/1.1\r\nHost: test.test\r\nTransfer-Encoding: chunked\r\n\r\n1\r\nc\r\n0\r\n\r\n\r\n"
+			},
+			assert: func(t *testing.T, br *bufio.Reader, s SerDe) {
+				for _, expected := range []struct{ path, body string }{{"/a", "a"}, {"/b", ""}, {"/c", "c"}} {
+					req, err := s.DeserializeReader(br)
+					require.NoError(t, err)
+					require.Equal(t, expected.path, req.URL.Path)
+					b, err := io.ReadAll(req.Body)
+					require.NoError(t, err)
+					require.Equal(t, expected.body, string(b))
+				}
+				_, err := s.DeserializeReader(br)
+				require.ErrorIs(t, err, io.EOF)
+			},
+		},
+		{
+			it: "leaves the bytes after the request buffered",
+			input: func(t *testing.T) string {
+				return "POST /a HTTP/1.1\r\nHost: test.test\r\nContent-Length: 1\r\n\r\narest"
+			},
+			assert: func(t *testing.T, br *bufio.Reader, s SerDe) {
+				_, err := s.DeserializeReader(br)
+				require.NoError(t, err)
+				rest, err := io.ReadAll(br)
+				require.NoError(t, err)
+				require.Equal(t, "rest", string(rest))
+			},
+		},
+		{
+			it:   "applies the configured options",
+			opts: []Option{WithPreserveHeaderOrder(true), WithValidateHostConsistency(true)},
+			input: func(t *testing.T) string {
+				return "GET /a HTTP/1.1\r\nX-B: b\r\nHost: test.test\r\n\r\nGET http://test.test/b HTTP/1.1\r\nHost: other.test\r\n\r\n"
+			},
+			assert: func(t *testing.T, br *bufio.Reader, s SerDe) {
+				req, err := s.DeserializeReader(br)
+				require.NoError(t, err)
+				ser, err := s.Serialize(req)
+				require.NoError(t, err)
+				require.True(t, strings.HasPrefix(string(ser), "GET /a HTTP/1.1\r\nX-B: b\r\nHost: test.test\r\n"))
+				_, err = s.DeserializeReader(br)
+				require.ErrorIs(t, err, ErrHostMismatch)
+			},
+		},
+		{
+			it: "returns an error for truncated bodies",
+			input: func(t *testing.T) string {
+				return "POST /a HTTP/1.1\r\nHost: test.test\r\nContent-Length: 10\r\n\r\nshort"
+			},
+			assert: func(t *testing.T, br *bufio.Reader, s SerDe) {
+				_, err := s.DeserializeReader(br)
+				require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+			},
+		},
+		{
+			it: "returns an error for malformed chunk sizes",
+			input: func(t *testing.T) string {
+				return "POST /a HTTP/1.1\r\nHost: test.test\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\n"
+			},
+			assert: func(t *testing.T, br *bufio.Reader, s SerDe) {
+				_, err := s.DeserializeReader(br)
+				require.Error(t, err)
+			},
+		},
+		{
+			it:   "gives up on bodies over the maximum input size before reading them",
+			opts: []Option{WithMaxInputBytes(80)},
+			input: func(t *testing.T) string {
+				return "POST /a HTTP/1.1\r\nHost: test.test\r\nContent-Length: 1099511627776\r\n\r\nbody"
+			},
+			assert: func(t *testing.T, br *bufio.Reader, s SerDe) {
+				_, err := s.DeserializeReader(br)
+				require.ErrorIs(t, err, ErrInputTooLarge)
+				rest, err := io.ReadAll(br)
+				require.NoError(t, err)
+				require.Equal(t, "body", string(rest))
+			},
+		},
+		{
+			it:   "gives up on heads and chunks over the maximum input size",
+			opts: []Option{WithMaxInputBytes(64)},
+			input: func(t *testing.T) string {
+				return "GET /a HTTP/1.1\r\nX-Long: " + strings.Repeat("a", 4096) + "\r\n\r\n" +
+					"POST /b HTTP/1.1\r\nHost: test.test\r\nTransfer-Encoding: chunked\r\n\r\n40\r\n"
+			},
+			assert: func(t *testing.T, br *bufio.Reader, s SerDe) {
+				_, err := s.DeserializeReader(br)
+				require.ErrorIs(t, err, ErrInputTooLarge)
+				_, err = br.ReadString('\n')
+				require.NoError(t, err)
+				_, err = s.DeserializeReader(br)
+				require.ErrorIs(t, err, ErrInputTooLarge)
+			},
+		},
+		{
+			it:   "reads requests within the maximum input size",
+			opts: []Option{WithMaxInputBytes(64)},
+			input: func(t *testing.T) string {
+				return "POST /a HTTP/1.1\r\nHost: test.test\r\nContent-Length: 4\r\n\r\nbody"
+			},
+			assert: func(t *testing.T, br *bufio.Reader, s SerDe) {
+				req, err := s.DeserializeReader(br)
+				require.NoError(t, err)
+				b, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				require.Equal(t, "body", string(b))
+			},
+		},
+		{
+			it: "returns a parse error for malformed requests",
+			input: func(t *testing.T) string {
+				return "INVALID"
+			},
+			assert: func(t *testing.T, br *bufio.Reader, s SerDe) {
+				_, err := s.DeserializeReader(br)
+				var parseErr *ParseError
+				require.ErrorAs(t, err, &parseErr)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			tt.assert(t, bufio.NewReader(strings.NewReader(tt.input(t))), New(tt.opts...))
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package http_serde
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// SerializeWithSummary serializes the request and describes it in one line
+// for audit logs, as in "POST /api/x 1234 bytes from test.test", giving the
+// method, path, body length and host. The query is left out of the summary as
+// it may hold credentials.
+func (s *serde) SerializeWithSummary(request *http.Request) ([]byte, string, error) {
+	ser, _, l, err := s.serialize(request)
+	if err != nil {
+		return nil, "", err
+	}
+	host := request.Host
+	path := "/"
+	if request.URL != nil {
+		if host == "" {
+			host = request.URL.Host
+		}
+		if p := request.URL.EscapedPath(); p != "" {
+			path = p
+		}
+	}
+	if request.Method == http.MethodConnect {
+		path = host
+	}
+	return ser, fmt.Sprintf("%s %s %d bytes from %s", request.Method, path, l, host), nil
+}
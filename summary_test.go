@@ -0,0 +1,54 @@
+package http_serde
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializeWithSummary(t *testing.T) {
+	tests := []struct {
+		it              string
+		setup           func(t *testing.T) *http.Request
+		expectedSummary string
+	}{
+		{
+			it: "summarizes method, path, body length and host",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodPost, "http://test.test/api/x?token=secret", "test")
+			},
+			expectedSummary: "POST /api/x 4 bytes from test.test",
+		},
+		{
+			it: "summarizes requests without a body or path",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodGet, "http://test.test", "")
+			},
+			expectedSummary: "GET / 0 bytes from test.test",
+		},
+		{
+			it: "summarizes CONNECT requests with their authority",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodConnect, "http://test.test:443", "")
+			},
+			expectedSummary: "CONNECT test.test:443 0 bytes from test.test:443",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			s := New()
+			ser, summary, err := s.SerializeWithSummary(tt.setup(t))
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedSummary, summary)
+			expected, err := s.Serialize(tt.setup(t))
+			require.NoError(t, err)
+			require.Equal(t, expected, ser)
+		})
+	}
+
+	ser, summary, err := New().SerializeWithSummary(nil)
+	require.Error(t, err)
+	require.Nil(t, ser)
+	require.Empty(t, summary)
+}
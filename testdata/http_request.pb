@@ -0,0 +1,5 @@
+
+POST/search?q=cafHTTP/1.1"
+Host	test.test"
+X-NameJos"
+Content-Length4*test
\ No newline at end of file
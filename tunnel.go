@@ -0,0 +1,56 @@
+package http_serde
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// SerializeTunnel serializes a CONNECT request together with the request
+// sent through the tunnel it established. Both are written one after the
+// other in the wire format, which delimits them on its own.
+func SerializeTunnel(connect, inner *http.Request) ([]byte, error) {
+	if connect == nil || inner == nil {
+		return nil, errors.New("serialize called on nil request")
+	}
+	if connect.Method != http.MethodConnect {
+		return nil, ErrMalformedTunnel
+	}
+	s := New()
+	head, err := s.Serialize(connect)
+	if err != nil {
+		return nil, err
+	}
+	ser, err := s.Serialize(inner)
+	if err != nil {
+		return nil, err
+	}
+	return append(head, ser...), nil
+}
+
+// DeserializeTunnel deserializes the CONNECT request and tunneled request
+// written by SerializeTunnel.
+func DeserializeTunnel(serialized []byte) (*http.Request, *http.Request, error) {
+	s := New()
+	br := bufio.NewReader(bytes.NewReader(serialized))
+	connect, err := s.DeserializeReader(br)
+	if err != nil {
+		return nil, nil, err
+	}
+	if connect.Method != http.MethodConnect {
+		return nil, nil, ErrMalformedTunnel
+	}
+	inner, err := s.DeserializeReader(br)
+	if errors.Is(err, io.EOF) {
+		return nil, nil, ErrMalformedTunnel
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := br.Peek(1); err == nil {
+		return nil, nil, ErrMalformedTunnel
+	}
+	return connect, inner, nil
+}
@@ -0,0 +1,138 @@
+package http_serde
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newConnectRequest(t *testing.T, authority string) *http.Request {
+	req, err := http.NewRequest(http.MethodConnect, "http://proxy.test", nil)
+	require.NoError(t, err)
+	req.URL = &url.URL{Host: authority}
+	req.Host = authority
+	return req
+}
+
+func TestSerializeTunnel(t *testing.T) {
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) (*http.Request, *http.Request)
+		assert func(t *testing.T, b []byte, err error)
+	}{
+		{
+			it: "returns an error if a request is nil",
+			setup: func(t *testing.T) (*http.Request, *http.Request) {
+				return newConnectRequest(t, "test.test:443"), nil
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.Error(t, err)
+				require.Nil(t, b)
+			},
+		},
+		{
+			it: "returns an error if the first request is not a CONNECT",
+			setup: func(t *testing.T) (*http.Request, *http.Request) {
+				return newRequest(t, http.MethodGet, "http://test.test/a", ""), newRequest(t, http.MethodGet, "http://test.test/b", "")
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.ErrorIs(t, err, ErrMalformedTunnel)
+			},
+		},
+		{
+			it: "serializes the CONNECT request with its authority followed by the inner request",
+			setup: func(t *testing.T) (*http.Request, *http.Request) {
+				return newConnectRequest(t, "test.test:443"), newRequest(t, http.MethodPost, "https://test.test/test", "test")
+			},
+			assert: func(t *testing.T, b []byte, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "CONNECT test.test:443 HTTP/1.1\r\nHost: test.test:443\r\nContent-Length: 0\r\n\r\n"+
+					"POST /test HTTP/1.1\r\nHost: test.test\r\nContent-Length: 4\r\n\r\ntest", string(b))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			b, err := SerializeTunnel(tt.setup(t))
+			tt.assert(t, b, err)
+		})
+	}
+}
+
+func TestDeserializeTunnel(t *testing.T) {
+	tests := []struct {
+		it     string
+		setup  func(t *testing.T) []byte
+		assert func(t *testing.T, connect, inner *http.Request, err error)
+	}{
+		{
+			it: "round-trips a CONNECT request and its inner request",
+			setup: func(t *testing.T) []byte {
+				b, err := SerializeTunnel(newConnectRequest(t, "test.test:443"), newRequest(t, http.MethodGet, "https://test.test/test?foo=bar", ""))
+				require.NoError(t, err)
+				return b
+			},
+			assert: func(t *testing.T, connect, inner *http.Request, err error) {
+				require.NoError(t, err)
+				require.Equal(t, http.MethodConnect, connect.Method)
+				require.Equal(t, "test.test:443", connect.Host)
+				require.Equal(t, "test.test:443", connect.RequestURI)
+				require.Equal(t, http.MethodGet, inner.Method)
+				require.Equal(t, "test.test", inner.Host)
+				require.Equal(t, "/test?foo=bar", inner.RequestURI)
+				b, err := io.ReadAll(inner.Body)
+				require.NoError(t, err)
+				require.Empty(t, b)
+			},
+		},
+		{
+			it: "returns an error without an inner request",
+			setup: func(t *testing.T) []byte {
+				return []byte("CONNECT test.test:443 HTTP/1.1\r\nHost: test.test:443\r\n\r\n")
+			},
+			assert: func(t *testing.T, connect, inner *http.Request, err error) {
+				require.ErrorIs(t, err, ErrMalformedTunnel)
+			},
+		},
+		{
+			it: "returns an error if the first request is not a CONNECT",
+			setup: func(t *testing.T) []byte {
+				return serializeAll(t, newRequest(t, http.MethodGet, "http://test.test/a", ""), newRequest(t, http.MethodGet, "http://test.test/b", ""))
+			},
+			assert: func(t *testing.T, connect, inner *http.Request, err error) {
+				require.ErrorIs(t, err, ErrMalformedTunnel)
+			},
+		},
+		{
+			it: "returns an error for trailing requests",
+			setup: func(t *testing.T) []byte {
+				b, err := SerializeTunnel(newConnectRequest(t, "test.test:443"), newRequest(t, http.MethodGet, "https://test.test/test", ""))
+				require.NoError(t, err)
+				return append(b, serializeAll(t, newRequest(t, http.MethodGet, "https://test.test/other", ""))...)
+			},
+			assert: func(t *testing.T, connect, inner *http.Request, err error) {
+				require.ErrorIs(t, err, ErrMalformedTunnel)
+				require.Nil(t, connect)
+				require.Nil(t, inner)
+			},
+		},
+		{
+			it: "returns an error if the serialized tunnel is invalid",
+			setup: func(t *testing.T) []byte {
+				return []byte("INVALID")
+			},
+			assert: func(t *testing.T, connect, inner *http.Request, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			connect, inner, err := DeserializeTunnel(tt.setup(t))
+			tt.assert(t, connect, inner, err)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package http_serde
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// EffectiveURL returns the absolute URL the request was addressed to. Server
+// requests, such as deserialized ones, only carry the host in their Host
+// field and no scheme, which is then https for TLS connections and
+// defaultScheme, or http if empty, otherwise.
+func EffectiveURL(request *http.Request, defaultScheme string) (*url.URL, error) {
+	if request == nil {
+		return nil, errors.New("effective url called on nil request")
+	}
+	var u *url.URL
+	if request.RequestURI != "" {
+		parsed, err := url.ParseRequestURI(request.RequestURI)
+		if err != nil {
+			return nil, err
+		}
+		u = parsed
+	} else if request.URL != nil {
+		clone := *request.URL
+		u = &clone
+	} else {
+		return nil, errors.New("request has no URL")
+	}
+	if u.IsAbs() && u.Host != "" {
+		return u, nil
+	}
+	if u.Scheme == "" && request.URL != nil {
+		u.Scheme = request.URL.Scheme
+	}
+	if u.Scheme == "" {
+		u.Scheme = defaultScheme
+		if request.TLS != nil {
+			u.Scheme = "https"
+		}
+		if u.Scheme == "" {
+			u.Scheme = "http"
+		}
+	}
+	if u.Host == "" {
+		u.Host = request.Host
+	}
+	if u.Host == "" && request.URL != nil {
+		u.Host = request.URL.Host
+	}
+	if u.Host == "" {
+		return nil, errors.New("request has no host")
+	}
+	return u, nil
+}
@@ -0,0 +1,108 @@
+package http_serde
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectiveURL(t *testing.T) {
+	deserialize := func(t *testing.T, serialized string) *http.Request {
+		req, err := New().Deserialize([]byte(serialized))
+		require.NoError(t, err)
+		return req
+	}
+	tests := []struct {
+		it            string
+		defaultScheme string
+		setup         func(t *testing.T) *http.Request
+		assert        func(t *testing.T, u string, err error)
+	}{
+		{
+			it: "returns an error if http request is nil",
+			setup: func(t *testing.T) *http.Request {
+				return nil
+			},
+			assert: func(t *testing.T, u string, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			it:            "builds the URL of deserialized requests from their host and target",
+			defaultScheme: "https",
+			setup: func(t *testing.T) *http.Request {
+				return deserialize(t, "GET /test/a%2Fb?foo=bar HTTP/1.1\r\nHost: test.test:8443\r\n\r\n")
+			},
+			assert: func(t *testing.T, u string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "https://test.test:8443/test/a%2Fb?foo=bar", u)
+			},
+		},
+		{
+			it: "falls back to http without a default scheme",
+			setup: func(t *testing.T) *http.Request {
+				return deserialize(t, "GET /test HTTP/1.1\r\nHost: test.test\r\n\r\n")
+			},
+			assert: func(t *testing.T, u string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "http://test.test/test", u)
+			},
+		},
+		{
+			it:            "uses https for requests received over TLS",
+			defaultScheme: "http",
+			setup: func(t *testing.T) *http.Request {
+				req := deserialize(t, "GET /test HTTP/1.1\r\nHost: test.test\r\n\r\n")
+				req.TLS = &tls.ConnectionState{}
+				return req
+			},
+			assert: func(t *testing.T, u string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "https://test.test/test", u)
+			},
+		},
+		{
+			it:            "keeps absolute-form targets",
+			defaultScheme: "https",
+			setup: func(t *testing.T) *http.Request {
+				return deserialize(t, "GET http://other.test/test HTTP/1.1\r\nHost: other.test\r\n\r\n")
+			},
+			assert: func(t *testing.T, u string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "http://other.test/test", u)
+			},
+		},
+		{
+			it: "returns the URL of client requests",
+			setup: func(t *testing.T) *http.Request {
+				return newRequest(t, http.MethodGet, "https://test.test/test?foo=bar", "")
+			},
+			assert: func(t *testing.T, u string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "https://test.test/test?foo=bar", u)
+			},
+		},
+		{
+			it: "returns an error without a host",
+			setup: func(t *testing.T) *http.Request {
+				return deserialize(t, "GET /test HTTP/1.0\r\n\r\n")
+			},
+			assert: func(t *testing.T, u string, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			u, err := EffectiveURL(tt.setup(t), tt.defaultScheme)
+			if err != nil {
+				tt.assert(t, "", err)
+				return
+			}
+			require.True(t, u.IsAbs())
+			tt.assert(t, u.String(), err)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package http_serde
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// VaryKey builds a cache key from the request headers named by vary, the
+// values of the Vary response headers. Names are matched case-insensitively
+// and may hold comma-separated lists, so the key does not depend on how the
+// response spelled or ordered them. A header the request lacks counts as
+// empty. Vary: * fails with ErrVaryWildcard, as no other request matches it.
+func VaryKey(request *http.Request, vary []string) (string, error) {
+	if request == nil {
+		return "", errors.New("vary key called on nil request")
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, value := range vary {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			switch {
+			case name == "*":
+				return "", ErrVaryWildcard
+			case name == "" || seen[name]:
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		values := request.Header.Values(name)
+		if name == "Host" {
+			values = []string{request.Host}
+		}
+		b.WriteString(strings.ToLower(name))
+		b.WriteByte(':')
+		for i, value := range values {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(strings.TrimSpace(value))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,78 @@
+package http_serde
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaryKey(t *testing.T) {
+	newVaried := func(t *testing.T) *http.Request {
+		req := newRequest(t, http.MethodGet, "http://test.test/test", "")
+		req.Header.Set("Accept-Encoding", "gzip, br")
+		req.Header.Set("Accept-Language", "en")
+		req.Header.Set("User-Agent", "test/1.0")
+		return req
+	}
+	tests := []struct {
+		it       string
+		setup    func(t *testing.T) *http.Request
+		vary     []string
+		expected string
+		err      error
+	}{
+		{
+			it:       "keys on the varying headers only",
+			setup:    newVaried,
+			vary:     []string{"Accept-Encoding, Accept-Language"},
+			expected: "accept-encoding:gzip, br\naccept-language:en\n",
+		},
+		{
+			it:       "ignores the spelling, order and repetition of names",
+			setup:    newVaried,
+			vary:     []string{"accept-language", "ACCEPT-ENCODING,accept-language"},
+			expected: "accept-encoding:gzip, br\naccept-language:en\n",
+		},
+		{
+			it: "joins repeated header values",
+			setup: func(t *testing.T) *http.Request {
+				req := newVaried(t)
+				req.Header.Add("Accept-Language", " fr ")
+				return req
+			},
+			vary:     []string{"Accept-Language"},
+			expected: "accept-language:en,fr\n",
+		},
+		{
+			it:       "treats missing headers as empty",
+			setup:    newVaried,
+			vary:     []string{"Origin"},
+			expected: "origin:\n",
+		},
+		{
+			it:    "rejects Vary: *",
+			setup: newVaried,
+			vary:  []string{"Accept-Encoding, *"},
+			err:   ErrVaryWildcard,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			req := tt.setup(t)
+			got, err := VaryKey(req, tt.vary)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, got)
+			again, err := VaryKey(req, tt.vary)
+			require.NoError(t, err)
+			require.Equal(t, got, again)
+		})
+	}
+
+	_, err := VaryKey(nil, []string{"Accept"})
+	require.Error(t, err)
+}
@@ -0,0 +1,52 @@
+package http_serde
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// SerializeTo writes the serialized request to w. Bodies of a known length
+// implementing io.WriterTo are written straight to w instead of being
+// buffered first, when the configured format allows it. Such bodies are
+// consumed and closed rather than replaced.
+func (s *serde) SerializeTo(w io.Writer, request *http.Request) error {
+	if request == nil {
+		return errors.New("serialize called on nil request")
+	}
+	body, ok := request.Body.(io.WriterTo)
+	if !ok || request.ContentLength <= 0 || len(request.TransferEncoding) > 0 || !s.streamsBody() {
+		ser, err := s.Serialize(request)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(ser)
+		return err
+	}
+	request.Header.Set("Content-Length", strconv.FormatInt(request.ContentLength, 10))
+	_, head, err := s.dump(request, nil, false)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+	n, err := body.WriteTo(w)
+	if err != nil {
+		return err
+	}
+	if n != request.ContentLength {
+		return fmt.Errorf("request body length %d does not match content length %d", n, request.ContentLength)
+	}
+	return request.Body.Close()
+}
+
+// streamsBody reports whether the body can be written as is after the head,
+// that is when no option needs to alter or encode it.
+func (s *serde) streamsBody() bool {
+	_, raw := s.activeCodec().(RawCodec)
+	return raw && !s.placeholderBody && s.idempotencyKey == nil && s.encryptionKey == nil &&
+		!s.sortParts && len(s.jsonRedactions) == 0 && s.stableIDHeader == ""
+}
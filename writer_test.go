@@ -0,0 +1,136 @@
+package http_serde
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readerOnly hides any io.WriterTo implementation of the wrapped reader.
+type readerOnly struct {
+	io.Reader
+}
+
+func TestSerializeTo(t *testing.T) {
+	tests := []struct {
+		it     string
+		opts   []Option
+		setup  func(t *testing.T) *http.Request
+		assert func(t *testing.T, got string, err error)
+	}{
+		{
+			it: "returns an error if http request is nil",
+			setup: func(t *testing.T) *http.Request {
+				return nil
+			},
+			assert: func(t *testing.T, got string, err error) {
+				require.Error(t, err)
+				require.Empty(t, got)
+			},
+		},
+		{
+			it: "writes bodies implementing io.WriterTo as Serialize does",
+			setup: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodPost, "http://test.test/test", bytes.NewReader([]byte("test")))
+				require.NoError(t, err)
+				_, ok := req.Body.(io.WriterTo)
+				require.True(t, ok)
+				return req
+			},
+			assert: func(t *testing.T, got string, err error) {
+				require.NoError(t, err)
+				ser, err := New().Serialize(newRequest(t, http.MethodPost, "http://test.test/test", "test"))
+				require.NoError(t, err)
+				require.Equal(t, string(ser), got)
+			},
+		},
+		{
+			it: "returns an error if the body does not match the content length",
+			setup: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodPost, "http://test.test/test", bytes.NewReader([]byte("test")))
+				require.NoError(t, err)
+				req.ContentLength = 5
+				return req
+			},
+			assert: func(t *testing.T, got string, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			it: "writes other bodies as Serialize does",
+			setup: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodPost, "http://test.test/test", readerOnly{strings.NewReader("test")})
+				require.NoError(t, err)
+				return req
+			},
+			assert: func(t *testing.T, got string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "POST /test HTTP/1.1\r\nHost: test.test\r\nContent-Length: 4\r\n\r\ntest", got)
+			},
+		},
+		{
+			it:   "applies body options to bodies implementing io.WriterTo",
+			opts: []Option{WithBodyPlaceholder('*')},
+			setup: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodPost, "http://test.test/test", bytes.NewReader([]byte("test")))
+				require.NoError(t, err)
+				return req
+			},
+			assert: func(t *testing.T, got string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "POST /test HTTP/1.1\r\nHost: test.test\r\nContent-Length: 4\r\n\r\n****", got)
+			},
+		},
+		{
+			it: "lets idempotency key generators read bodies implementing io.WriterTo",
+			opts: []Option{WithIdempotencyKey(func(r *http.Request) string {
+				b, _ := io.ReadAll(r.Body)
+				return string(b)
+			}, "Idempotency-Key")},
+			setup: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodPost, "http://test.test/test", bytes.NewReader([]byte("test")))
+				require.NoError(t, err)
+				return req
+			},
+			assert: func(t *testing.T, got string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "POST /test HTTP/1.1\r\nHost: test.test\r\nContent-Length: 4\r\nIdempotency-Key: test\r\n\r\ntest", got)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.it, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := New(tt.opts...).SerializeTo(&buf, tt.setup(t))
+			tt.assert(t, buf.String(), err)
+		})
+	}
+}
+
+func BenchmarkSerializeTo(b *testing.B) {
+	body := bytes.Repeat([]byte("a"), 1<<20)
+	benchmarks := []struct {
+		name string
+		body func() io.Reader
+	}{
+		{name: "WriterTo", body: func() io.Reader { return bytes.NewReader(body) }},
+		{name: "Reader", body: func() io.Reader { return readerOnly{bytes.NewReader(body)} }},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			s := New()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				req, _ := http.NewRequest(http.MethodPost, "http://test.test/test", bm.body())
+				req.ContentLength = int64(len(body))
+				if err := s.SerializeTo(io.Discard, req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}